@@ -1,9 +1,15 @@
 package util
 
 import (
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// QueryDateFormat is the format of dates in Personio API query parameters and request bodies
+const QueryDateFormat = "2006-01-02"
+
 // PersonioDateMax is the maximum representable time.Time value for the Personio API
 var PersonioDateMax, _ = time.Parse(time.RFC3339, "9999-12-31T23:59:59.999Z")
 
@@ -22,3 +28,44 @@ func GetTimeIntersection(start1 time.Time, end1 time.Time, start2 time.Time, end
 
 	return endMin.Sub(startMax)
 }
+
+// FormatPersonioDate formats t as Personio date in the specified location (or t's own location if loc is nil)
+func FormatPersonioDate(t time.Time, loc *time.Location) string {
+	if loc != nil {
+		t = t.In(loc)
+	}
+	return t.Format(QueryDateFormat)
+}
+
+// ParsePersonioDate parses a Personio date (YYYY-MM-DD) to midnight UTC of that day
+func ParsePersonioDate(s string) (time.Time, error) {
+	return time.Parse(QueryDateFormat, strings.TrimSpace(s))
+}
+
+// ParseRetryAfter parses a Retry-After header value given as number of seconds or as HTTP-date relative to now
+//
+// HTTP-dates in the past yield 0. Empty, negative or otherwise unparsable values are reported as not ok.
+func ParseRetryAfter(headerValue string, now time.Time) (time.Duration, bool) {
+
+	value := strings.TrimSpace(headerValue)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 || seconds > int64(1<<63-1)/int64(time.Second) {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	wait := date.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
@@ -0,0 +1,87 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+type personioDateTestCase struct {
+	time     time.Time
+	loc      *time.Location
+	wantDate string
+}
+
+func TestFormatPersonioDate(t *testing.T) {
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Errorf("Failed to load location: %s", err)
+		return
+	}
+
+	lateUtc := time.Date(2022, 9, 5, 23, 30, 0, 0, time.UTC)
+	dateCases := []personioDateTestCase{
+		{time: lateUtc, loc: nil, wantDate: "2022-09-05"},
+		{time: lateUtc, loc: time.UTC, wantDate: "2022-09-05"},
+		{time: lateUtc, loc: berlin, wantDate: "2022-09-06"},
+		{time: time.Date(2022, 9, 6, 0, 30, 0, 0, berlin), loc: nil, wantDate: "2022-09-06"},
+	}
+
+	for testNumber, testCase := range dateCases {
+		date := FormatPersonioDate(testCase.time, testCase.loc)
+		if date != testCase.wantDate {
+			t.Errorf("[%d] Expected date \"%s\", got \"%s\"", testNumber, testCase.wantDate, date)
+			continue
+		}
+
+		parsed, err := ParsePersonioDate(date)
+		if err != nil {
+			t.Errorf("[%d] Failed to parse date \"%s\": %s", testNumber, date, err)
+			continue
+		}
+
+		if FormatPersonioDate(parsed, nil) != date {
+			t.Errorf("[%d] Expected round-trip of \"%s\", got \"%s\"", testNumber, date, FormatPersonioDate(parsed, nil))
+		}
+	}
+
+	for _, invalid := range []string{"", "2022-13-01", "01.02.2022", "2022-09-05T00:00:00Z"} {
+		_, err := ParsePersonioDate(invalid)
+		if err == nil {
+			t.Errorf("Expected error parsing \"%s\"", invalid)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+
+	now := time.Date(2022, 9, 5, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		value    string
+		wantWait time.Duration
+		wantOk   bool
+	}{
+		// seconds
+		{value: "0", wantWait: 0, wantOk: true},
+		{value: "120", wantWait: 2 * time.Minute, wantOk: true},
+		{value: " 5 ", wantWait: 5 * time.Second, wantOk: true},
+		{value: "-5", wantOk: false},
+		{value: "99999999999999999999", wantOk: false},
+		// HTTP-dates
+		{value: "Mon, 05 Sep 2022 12:00:30 GMT", wantWait: 30 * time.Second, wantOk: true},
+		{value: "Monday, 05-Sep-22 12:01:00 GMT", wantWait: time.Minute, wantOk: true},
+		{value: "Mon Sep  5 12:00:10 2022", wantWait: 10 * time.Second, wantOk: true},
+		{value: "Mon, 05 Sep 2022 11:00:00 GMT", wantWait: 0, wantOk: true},
+		// garbage
+		{value: "", wantOk: false},
+		{value: "soon", wantOk: false},
+		{value: "1.5", wantOk: false},
+	}
+
+	for testNumber, testCase := range testCases {
+		wait, ok := ParseRetryAfter(testCase.value, now)
+		if ok != testCase.wantOk || wait != testCase.wantWait {
+			t.Errorf("[%d] Expected %s (ok %t) for %q, got %s (ok %t)", testNumber, testCase.wantWait, testCase.wantOk, testCase.value, wait, ok)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	util "github.com/giantswarm/personio-go"
+)
+
+// AbsenceBreakdown is the effective duration of an absence on a single day
+type AbsenceBreakdown struct {
+	Date              string  `json:"date"`
+	EffectiveDuration float64 `json:"effective_duration"`
+}
+
+// Absence is a single absence period entry of the newer /company/absence-periods endpoint
+//
+// EffectiveDuration and the breakdowns' durations are given in MeasurementUnit ("days" or "hours")
+type Absence struct {
+	Id                int64        `json:"id"`
+	Status            string       `json:"status"`
+	Comment           string       `json:"comment"`
+	StartDate         time.Time    `json:"start_date"`
+	EndDate           time.Time    `json:"end_date"`
+	HalfDayStart      PersonioBool `json:"half_day_start"`
+	HalfDayEnd        PersonioBool `json:"half_day_end"`
+	MeasurementUnit   string       `json:"measurement_unit"`
+	EffectiveDuration float64      `json:"effective_duration"`
+	TimeOffType       struct {
+		Type       string `json:"type"`
+		Attributes struct {
+			Id       int64  `json:"id"`
+			Name     string `json:"name"`
+			Category string `json:"category"`
+		} `json:"attributes"`
+	} `json:"time_off_type"`
+	Employee   Employee           `json:"employee"`
+	Breakdowns []AbsenceBreakdown `json:"breakdowns"`
+	CreatedBy  string             `json:"created_by"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
+
+// absenceContainer is the typed object returned for absence periods by Personio
+type absenceContainer struct {
+	Type       string  `json:"type"`
+	Attributes Absence `json:"attributes"`
+}
+
+// ToTimeOff converts the Absence to the legacy TimeOff representation
+//
+// DaysCount is only set for absences measured in days, the per-day breakdowns are lost
+func (a *Absence) ToTimeOff() *TimeOff {
+	timeOff := &TimeOff{
+		Id:                a.Id,
+		Status:            a.Status,
+		Comment:           a.Comment,
+		StartDate:         a.StartDate,
+		EndDate:           a.EndDate,
+		HalfDayStart:      a.HalfDayStart,
+		HalfDayEnd:        a.HalfDayEnd,
+		MeasurementUnit:   a.MeasurementUnit,
+		EffectiveDuration: a.EffectiveDuration,
+		TimeOffType:       a.TimeOffType,
+		Employee:          a.Employee,
+		CreatedBy:         a.CreatedBy,
+		CreatedAt:         a.CreatedAt,
+		UpdatedAt:         a.UpdatedAt,
+	}
+
+	if a.MeasurementUnit == "days" {
+		timeOff.DaysCount = a.EffectiveDuration
+	}
+
+	return timeOff
+}
+
+// GetAbsences returns the absence periods matching the specified start and end dates (inclusive, ignored if nil)
+//
+// Parameters offset and limit are not bound by the Personio APIs limits
+func (personio *Client) GetAbsences(start *time.Time, end *time.Time, offset int, limit int) ([]*Absence, error) {
+
+	query := url.Values{}
+	if start != nil {
+		query.Add("start_date", util.FormatPersonioDate(*start, nil))
+	}
+	if end != nil {
+		query.Add("end_date", util.FormatPersonioDate(*end, nil))
+	}
+	results, count, err := personio.getPages("/company/absence-periods", query, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// unpack Absence elements
+	absences := make([]*Absence, count)
+	idx := 0
+	for i := range results {
+		for j := range results[i].Data {
+			var result absenceContainer
+			err = json.Unmarshal(results[i].Data[j], &result)
+			if err != nil {
+				return nil, err
+			}
+			personio.applyAttributeConfig(&result.Attributes.Employee)
+			absences[idx] = &result.Attributes
+			idx++
+		}
+	}
+
+	return absences, nil
+}
@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type absenceTestCase struct {
+	start         *time.Time
+	end           *time.Time
+	wantIds       []int64
+	wantDaysCount []float64
+}
+
+func TestClient_GetAbsences(t *testing.T) {
+
+	tsEarly := makeTime("2022-09-01T00:00:00Z")
+	tsMiddle := makeTime("2022-09-10T00:00:00Z")
+	absenceCases := []absenceTestCase{
+		{start: nil, end: nil, wantIds: []int64{140000001, 140000002}, wantDaysCount: []float64{0, 2.5}},
+		{start: &tsEarly, end: &tsMiddle, wantIds: []int64{140000001}, wantDaysCount: []float64{0}},
+		{start: &tsMiddle, end: nil, wantIds: []int64{140000002}, wantDaysCount: []float64{2.5}},
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	for testNumber, testCase := range absenceCases {
+		absences, err := personio.GetAbsences(testCase.start, testCase.end, 0, 100)
+		if err != nil {
+			t.Errorf("[%d] Failed to query absences: %s", testNumber, err)
+			continue
+		}
+
+		if len(testCase.wantIds) != len(absences) {
+			t.Errorf("[%d] Expected %d absences, got %d", testNumber, len(testCase.wantIds), len(absences))
+			continue
+		}
+
+		for i, id := range testCase.wantIds {
+			if absences[i].Id != id {
+				t.Errorf("[%d] Expected absence with ID %d, got %d", testNumber, id, absences[i].Id)
+				continue
+			}
+
+			if len(absences[i].Breakdowns) == 0 {
+				t.Errorf("[%d] Absence with ID %d has no breakdowns", testNumber, id)
+			}
+
+			timeOff := absences[i].ToTimeOff()
+			if timeOff.Id != id || timeOff.DaysCount != testCase.wantDaysCount[i] || timeOff.TimeOffType.Attributes.Id != absences[i].TimeOffType.Attributes.Id {
+				t.Errorf("[%d] Unexpected time-off conversion of absence with ID %d: %+v", testNumber, id, timeOff)
+			}
+		}
+	}
+}
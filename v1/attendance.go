@@ -0,0 +1,382 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	util "github.com/giantswarm/personio-go"
+)
+
+// ErrInvalidAttendance is returned for attendances rejected locally before sending them to Personio
+var ErrInvalidAttendance = errors.New("invalid attendance")
+
+// Attendance is a single attendance period entry
+//
+// Date is a Personio date (YYYY-MM-DD), StartTime and EndTime are wall-clock times (HH:MM) and Break is in minutes.
+// Project is the raw project object the attendance is booked on, nil if none.
+// Location is the time zone the wall-clock times are interpreted in by Start and End. Personio does not return it,
+// GetAttendances sets it to the location configured via WithLocation and it may be overridden per record.
+type Attendance struct {
+	Id          int64       `json:"id"`
+	EmployeeId  int64       `json:"employee"`
+	Date        string      `json:"date"`
+	StartTime   string      `json:"start_time"`
+	EndTime     string      `json:"end_time"`
+	Break       int         `json:"break"`
+	Comment     string      `json:"comment"`
+	Status      string      `json:"status"`
+	Project     interface{} `json:"project"`
+	IsHoliday   bool        `json:"is_holiday"`
+	IsOnTimeOff bool        `json:"is_on_time_off"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+
+	Location *time.Location `json:"-"`
+}
+
+// attendanceClockFormat is the layout of an attendance's Date combined with a wall-clock time
+const attendanceClockFormat = util.QueryDateFormat + " 15:04"
+
+// at returns the instant of the wall-clock time clock on the attendance's Date, days later
+func (a *Attendance) at(clock string, days int) (time.Time, error) {
+
+	loc := a.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	t, err := time.ParseInLocation(attendanceClockFormat, a.Date+" "+clock, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid attendance time \"%s %s\": %w", a.Date, clock, err)
+	}
+	if days == 0 {
+		return t, nil
+	}
+
+	// add calendar days rather than 24h to keep the wall-clock time across DST changes
+	return time.Date(t.Year(), t.Month(), t.Day()+days, t.Hour(), t.Minute(), 0, 0, loc), nil
+}
+
+// Start returns the instant the attendance starts at, StartTime on Date in the attendance's Location (UTC if nil)
+func (a *Attendance) Start() (time.Time, error) {
+	return a.at(a.StartTime, 0)
+}
+
+// End returns the instant the attendance ends at, EndTime on Date in the attendance's Location (UTC if nil)
+//
+// An EndTime not after StartTime is taken to be on the next day, e.g. for night shifts.
+func (a *Attendance) End() (time.Time, error) {
+
+	end, err := a.at(a.EndTime, 0)
+	if err != nil {
+		return time.Time{}, err
+	}
+	start, err := a.Start()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !end.After(start) {
+		return a.at(a.EndTime, 1)
+	}
+
+	return end, nil
+}
+
+// attendanceContainer is the typed object returned for attendances by Personio
+type attendanceContainer struct {
+	Id         int64      `json:"id"`
+	Type       string     `json:"type"`
+	Attributes Attendance `json:"attributes"`
+}
+
+// attendancesQuery returns the query selecting the attendances of the specified employees (all if empty) between start and end
+func attendancesQuery(employeeIds []int64, start *time.Time, end *time.Time) url.Values {
+
+	// Personio requires both dates
+	queryStart := time.Time{}
+	if start != nil {
+		queryStart = *start
+	}
+	queryEnd := util.PersonioDateMax
+	if end != nil {
+		queryEnd = *end
+	}
+
+	query := url.Values{}
+	query.Add("start_date", util.FormatPersonioDate(queryStart, nil))
+	query.Add("end_date", util.FormatPersonioDate(queryEnd, nil))
+	for _, id := range employeeIds {
+		query.Add("employees[]", strconv.FormatInt(id, 10))
+	}
+
+	return query
+}
+
+// unpackAttendances unpacks the Attendance elements of the specified pages
+func (personio *Client) unpackAttendances(results []*pageResult, count int) ([]*Attendance, error) {
+
+	attendances := make([]*Attendance, 0, count)
+	for i := range results {
+		for j := range results[i].Data {
+			var result attendanceContainer
+			err := json.Unmarshal(results[i].Data[j], &result)
+			if err != nil {
+				return nil, err
+			}
+			if result.Attributes.Id == 0 {
+				result.Attributes.Id = result.Id
+			}
+			result.Attributes.Location = personio.location
+			attendances = append(attendances, &result.Attributes)
+		}
+	}
+
+	return attendances, nil
+}
+
+// GetAttendances returns the attendances of the specified employees (all if empty) between start and end dates (inclusive)
+//
+// Parameters offset and limit are not bound by the Personio APIs limits, all pages up to limit are fetched
+func (personio *Client) GetAttendances(employeeIds []int64, start *time.Time, end *time.Time, offset int, limit int) ([]*Attendance, error) {
+
+	results, count, err := personio.getPages("/company/attendances", attendancesQuery(employeeIds, start, end), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return personio.unpackAttendances(results, count)
+}
+
+// GetAttendancesPage returns a single page of attendances like GetAttendances together with Personio's paging metadata
+//
+// The limit is capped at the Personio API's maximum page size. The metadata is nil if Personio does not return any.
+func (personio *Client) GetAttendancesPage(employeeIds []int64, start *time.Time, end *time.Time, offset int, limit int) ([]*Attendance, *PageMetadata, error) {
+
+	if limit > maxPageSize("/company/attendances") {
+		limit = maxPageSize("/company/attendances")
+	}
+
+	result, err := personio.getPage(context.Background(), "/company/attendances", attendancesQuery(employeeIds, start, end), offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	attendances, err := personio.unpackAttendances([]*pageResult{result}, len(result.Data))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attendances, result.Metadata, nil
+}
+
+// GetEmployeeAttendancesOnDate returns the attendances of a single employee on the specified day
+func (personio *Client) GetEmployeeAttendancesOnDate(employeeId int64, date time.Time) ([]Attendance, error) {
+
+	attendances, err := personio.GetAttendances([]int64{employeeId}, &date, &date, 0, intMax)
+	if err != nil {
+		return nil, err
+	}
+
+	day := util.FormatPersonioDate(date, nil)
+	matched := make([]Attendance, 0, len(attendances))
+	for _, attendance := range attendances {
+		if attendance.EmployeeId == employeeId && attendance.Date == day {
+			matched = append(matched, *attendance)
+		}
+	}
+
+	return matched, nil
+}
+
+// GetEmployeeAttendanceByDay returns the attendances of a single employee between start and end dates (inclusive)
+// grouped by day
+//
+// Days are keyed by midnight in the location configured via WithLocation (UTC if none), days without attendances are
+// omitted. Multiple attendances on the same day (e.g. split shifts) are kept in the order returned by Personio.
+func (personio *Client) GetEmployeeAttendanceByDay(employeeId int64, start, end time.Time) (map[time.Time][]Attendance, error) {
+
+	attendances, err := personio.GetAttendances([]int64{employeeId}, &start, &end, 0, intMax)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := personio.location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	days := map[time.Time][]Attendance{}
+	for _, attendance := range attendances {
+		if attendance.EmployeeId != employeeId {
+			continue
+		}
+		day, err := time.ParseInLocation(util.QueryDateFormat, attendance.Date, loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date of attendance %d: %w", attendance.Id, err)
+		}
+		days[day] = append(days[day], *attendance)
+	}
+
+	return days, nil
+}
+
+// AttendanceInput is the specification of an attendance to be created
+//
+// StartTime and EndTime are wall-clock times (HH:MM) on Date, Break is in minutes.
+type AttendanceInput struct {
+	EmployeeId int64
+	Date       time.Time
+	StartTime  string
+	EndTime    string
+	Break      int
+	Comment    string
+}
+
+// attendanceInputBody is a single attendance of the request body of POST /company/attendances
+type attendanceInputBody struct {
+	EmployeeId int64  `json:"employee"`
+	Date       string `json:"date"`
+	StartTime  string `json:"start_time"`
+	EndTime    string `json:"end_time"`
+	Break      int    `json:"break"`
+	Comment    string `json:"comment,omitempty"`
+}
+
+// validate returns an ErrInvalidAttendance error if the attendance ends before it starts or has a negative break
+func (a *AttendanceInput) validate() error {
+	start, err := time.Parse("15:04", a.StartTime)
+	if err != nil {
+		return fmt.Errorf("%w: start time %q: %s", ErrInvalidAttendance, a.StartTime, err)
+	}
+	end, err := time.Parse("15:04", a.EndTime)
+	if err != nil {
+		return fmt.Errorf("%w: end time %q: %s", ErrInvalidAttendance, a.EndTime, err)
+	}
+	if !end.After(start) {
+		return fmt.Errorf("%w: end time %s not after start time %s", ErrInvalidAttendance, a.EndTime, a.StartTime)
+	}
+	if a.Break < 0 {
+		return fmt.Errorf("%w: negative break of %d minutes", ErrInvalidAttendance, a.Break)
+	}
+	return nil
+}
+
+// CreateAttendances creates the specified attendances and returns their IDs in the same order
+//
+// All attendances are checked before sending them, failing with ErrInvalidAttendance if one ends before it starts
+// (shifts past midnight have to be split) or has a negative break.
+func (personio *Client) CreateAttendances(attendances []AttendanceInput) ([]int64, error) {
+
+	bodies := make([]attendanceInputBody, len(attendances))
+	for i := range attendances {
+		err := attendances[i].validate()
+		if err != nil {
+			return nil, fmt.Errorf("attendance %d: %w", i, err)
+		}
+		bodies[i] = attendanceInputBody{
+			EmployeeId: attendances[i].EmployeeId,
+			Date:       util.FormatPersonioDate(attendances[i].Date, nil),
+			StartTime:  attendances[i].StartTime,
+			EndTime:    attendances[i].EndTime,
+			Break:      attendances[i].Break,
+			Comment:    attendances[i].Comment,
+		}
+	}
+
+	requestBody, err := json.Marshal(struct {
+		Attendances []attendanceInputBody `json:"attendances"`
+	}{bodies})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, personio.baseUrl+"/company/attendances", bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	body, err := personio.doRequestJson(req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data struct {
+			Id []int64 `json:"id"`
+		} `json:"data"`
+	}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Data.Id, nil
+}
+
+// AttendancePatch is a partial update of an attendance, only non-nil fields are changed
+//
+// StartTime and EndTime are wall-clock times (HH:MM), Break is in minutes.
+type AttendancePatch struct {
+	StartTime *string `json:"start_time,omitempty"`
+	EndTime   *string `json:"end_time,omitempty"`
+	Break     *int    `json:"break,omitempty"`
+	Comment   *string `json:"comment,omitempty"`
+}
+
+// UpdateAttendance changes the non-nil fields of patch of the attendance with the specified ID
+//
+// No update is sent if patch is empty. A negative break or, if both are set, an end time not after the start time
+// fail with ErrInvalidAttendance before sending.
+func (personio *Client) UpdateAttendance(id int64, patch AttendancePatch) error {
+
+	if patch == (AttendancePatch{}) {
+		return nil
+	}
+	if patch.Break != nil && *patch.Break < 0 {
+		return fmt.Errorf("%w: negative break of %d minutes", ErrInvalidAttendance, *patch.Break)
+	}
+	if patch.StartTime != nil && patch.EndTime != nil {
+		err := (&AttendanceInput{StartTime: *patch.StartTime, EndTime: *patch.EndTime}).validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	requestBody, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPatch, fmt.Sprintf("%s/company/attendances/%d", personio.baseUrl, id), bytes.NewReader(requestBody))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = personio.doRequestJson(req, true)
+	return err
+}
+
+// DeleteAttendance deletes the attendance with the specified ID
+//
+// Deleting an attendance that doesn't exist (anymore) fails with a StatusError of code 404, other failures can be
+// told apart by their code.
+func (personio *Client) DeleteAttendance(id int64) error {
+
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/company/attendances/%d", personio.baseUrl, id), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = personio.doRequestJson(req, true)
+	return err
+}
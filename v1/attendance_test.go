@@ -0,0 +1,418 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+type attendanceOnDateTestCase struct {
+	employeeId int64
+	date       time.Time
+	wantIds    []int64
+}
+
+func TestClient_GetEmployeeAttendancesOnDate(t *testing.T) {
+
+	attendanceCases := []attendanceOnDateTestCase{
+		{employeeId: 7161253, date: makeTime("2022-09-01T10:00:00Z"), wantIds: []int64{81230001, 81230002}},
+		{employeeId: 6205887, date: makeTime("2022-09-01T00:00:00Z"), wantIds: []int64{81230003}},
+		{employeeId: 6205887, date: makeTime("2022-09-02T00:00:00Z"), wantIds: []int64{}},
+		{employeeId: 7161253, date: makeTime("2022-09-02T23:00:00Z"), wantIds: []int64{81230004}},
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	for testNumber, testCase := range attendanceCases {
+		attendances, err := personio.GetEmployeeAttendancesOnDate(testCase.employeeId, testCase.date)
+		if err != nil {
+			t.Errorf("[%d] Failed to query attendances: %s", testNumber, err)
+			continue
+		}
+
+		if len(testCase.wantIds) != len(attendances) {
+			t.Errorf("[%d] Expected %d attendances, got %d", testNumber, len(testCase.wantIds), len(attendances))
+			continue
+		}
+
+		for i, id := range testCase.wantIds {
+			if attendances[i].Id != id {
+				t.Errorf("[%d] Expected attendance with ID %d, got %d", testNumber, id, attendances[i].Id)
+			}
+			if attendances[i].EmployeeId != testCase.employeeId {
+				t.Errorf("[%d] Expected attendance of employee %d, got %d", testNumber, testCase.employeeId, attendances[i].EmployeeId)
+			}
+		}
+	}
+}
+
+func TestClient_GetEmployeeAttendanceByDay(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	days, err := personio.GetEmployeeAttendanceByDay(7161253, makeTime("2022-09-01T00:00:00Z"), makeTime("2022-09-30T00:00:00Z"))
+	if err != nil {
+		t.Errorf("Failed to query attendances: %s", err)
+		return
+	}
+
+	// a split shift on the 1st, a single attendance on the 2nd
+	wantIds := map[time.Time][]int64{
+		makeTime("2022-09-01T00:00:00Z"): {81230001, 81230002},
+		makeTime("2022-09-02T00:00:00Z"): {81230004},
+	}
+	if len(days) != len(wantIds) {
+		t.Errorf("Expected %d days, got %d", len(wantIds), len(days))
+	}
+	for day, ids := range wantIds {
+		attendances := days[day]
+		if len(attendances) != len(ids) {
+			t.Errorf("Expected %d attendances on %s, got %d", len(ids), day.Format(time.RFC3339), len(attendances))
+			continue
+		}
+		for i, id := range ids {
+			if attendances[i].Id != id {
+				t.Errorf("Expected attendance with ID %d on %s, got %d", id, day.Format(time.RFC3339), attendances[i].Id)
+			}
+		}
+	}
+}
+
+func TestAttendance_StartEnd(t *testing.T) {
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Failed to load location: %s", err)
+	}
+
+	testCases := []struct {
+		location  *time.Location
+		id        int64
+		wantStart string
+		wantEnd   string
+	}{
+		// without location times are UTC
+		{location: nil, id: 81230006, wantStart: "2022-03-27T00:30:00Z", wantEnd: "2022-03-27T04:30:00Z"},
+		{location: nil, id: 81230007, wantStart: "2022-10-29T22:00:00Z", wantEnd: "2022-10-30T06:00:00Z"},
+		// start of DST shortens the attendance to 3h
+		{location: berlin, id: 81230006, wantStart: "2022-03-26T23:30:00Z", wantEnd: "2022-03-27T02:30:00Z"},
+		// end of DST lengthens the night shift to 9h
+		{location: berlin, id: 81230007, wantStart: "2022-10-29T20:00:00Z", wantEnd: "2022-10-30T05:00:00Z"},
+		{location: berlin, id: 81230003, wantStart: "2022-09-01T07:00:00Z", wantEnd: "2022-09-01T15:00:00Z"},
+	}
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	for testNumber, testCase := range testCases {
+		server, err := newTestServer()
+		if err != nil {
+			t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+			return
+		}
+
+		personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithLocation(testCase.location))
+		if err != nil {
+			_ = server.Close()
+			t.Errorf("[%d] Failed to create Personio API v1 client: %s", testNumber, err)
+			continue
+		}
+
+		attendances, err := personio.GetAttendances(nil, nil, nil, 0, intMax)
+		_ = server.Close()
+		if err != nil {
+			t.Errorf("[%d] Failed to query attendances: %s", testNumber, err)
+			continue
+		}
+
+		var attendance *Attendance
+		for _, a := range attendances {
+			if a.Id == testCase.id {
+				attendance = a
+			}
+		}
+		if attendance == nil {
+			t.Errorf("[%d] Attendance %d not found", testNumber, testCase.id)
+			continue
+		}
+		if attendance.Location != testCase.location {
+			t.Errorf("[%d] Expected location %v, got %v", testNumber, testCase.location, attendance.Location)
+		}
+
+		start, err := attendance.Start()
+		if err != nil {
+			t.Errorf("[%d] Failed to get start: %s", testNumber, err)
+			continue
+		}
+		end, err := attendance.End()
+		if err != nil {
+			t.Errorf("[%d] Failed to get end: %s", testNumber, err)
+			continue
+		}
+		if !start.Equal(makeTime(testCase.wantStart)) {
+			t.Errorf("[%d] Expected start %s, got %s", testNumber, testCase.wantStart, start.UTC())
+		}
+		if !end.Equal(makeTime(testCase.wantEnd)) {
+			t.Errorf("[%d] Expected end %s, got %s", testNumber, testCase.wantEnd, end.UTC())
+		}
+	}
+
+	invalid := Attendance{Date: "2022-09-01", StartTime: "9h"}
+	if _, err := invalid.Start(); err == nil {
+		t.Errorf("Expected error for invalid start time")
+	}
+}
+
+func TestClient_GetAttendancesPaging(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	// attendances are fetched with Personio's larger maximum page size
+	_, _, err = personio.GetAttendancesPage(nil, nil, nil, 0, intMax)
+	if err != nil {
+		t.Fatalf("Failed to query attendances page: %s", err)
+	}
+	if server.mock.lastLimit != 200 {
+		t.Errorf("Expected page size 200, got %d", server.mock.lastLimit)
+	}
+
+	// Personio returning fewer elements per page than requested must not truncate the result
+	server.mock.maxPageSize = 2
+
+	attendances, err := personio.GetAttendances(nil, nil, nil, 0, intMax)
+	if err != nil {
+		t.Fatalf("Failed to query attendances: %s", err)
+	}
+	if len(attendances) != 7 {
+		t.Errorf("Expected 7 attendances, got %d", len(attendances))
+	}
+
+	attendances, err = personio.GetAttendances(nil, nil, nil, 1, 3)
+	if err != nil {
+		t.Fatalf("Failed to query attendances: %s", err)
+	}
+	if len(attendances) != 3 || attendances[0].Id != 81230002 || attendances[2].Id != 81230004 {
+		t.Errorf("Expected attendances 81230002 to 81230004, got %d", len(attendances))
+	}
+
+	testCases := []struct {
+		offset    int
+		limit     int
+		wantIds   []int64
+		wantPage  int
+		wantPages int
+		wantTotal int
+	}{
+		{offset: 0, limit: 2, wantIds: []int64{81230001, 81230002}, wantPage: 0, wantPages: 4, wantTotal: 7},
+		{offset: 6, limit: 2, wantIds: []int64{81230007}, wantPage: 3, wantPages: 4, wantTotal: 7},
+	}
+
+	for testNumber, testCase := range testCases {
+		page, metadata, err := personio.GetAttendancesPage(nil, nil, nil, testCase.offset, testCase.limit)
+		if err != nil {
+			t.Errorf("[%d] Failed to query attendances page: %s", testNumber, err)
+			continue
+		}
+		if len(page) != len(testCase.wantIds) {
+			t.Errorf("[%d] Expected %d attendances, got %d", testNumber, len(testCase.wantIds), len(page))
+			continue
+		}
+		for i, id := range testCase.wantIds {
+			if page[i].Id != id {
+				t.Errorf("[%d] Expected attendance with ID %d, got %d", testNumber, id, page[i].Id)
+			}
+		}
+		if metadata == nil {
+			t.Errorf("[%d] Expected paging metadata", testNumber)
+			continue
+		}
+		if metadata.TotalElements != testCase.wantTotal || metadata.CurrentPage != testCase.wantPage || metadata.TotalPages != testCase.wantPages {
+			t.Errorf("[%d] Unexpected paging metadata %+v", testNumber, *metadata)
+		}
+	}
+}
+
+func TestClient_CreateAttendances(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	// a split shift
+	date := makeTime("2022-09-05T00:00:00+02:00")
+	ids, err := personio.CreateAttendances([]AttendanceInput{
+		{EmployeeId: 6205887, Date: date, StartTime: "08:00", EndTime: "12:00", Comment: "Cutting paper"},
+		{EmployeeId: 6205887, Date: date, StartTime: "13:00", EndTime: "17:30", Break: 15},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create attendances: %s", err)
+	}
+	if !reflect.DeepEqual(ids, []int64{81231000, 81231001}) {
+		t.Errorf("Expected IDs [81231000 81231001], got %v", ids)
+	}
+
+	var body map[string][]map[string]interface{}
+	err = json.Unmarshal(server.mock.lastAttendancesBody, &body)
+	if err != nil {
+		t.Fatalf("Failed to decode request body: %s", err)
+	}
+	want := map[string]interface{}{"employee": float64(6205887), "date": "2022-09-05", "start_time": "13:00", "end_time": "17:30", "break": float64(15)}
+	if len(body["attendances"]) != 2 || !reflect.DeepEqual(body["attendances"][1], want) {
+		t.Errorf("Expected second attendance %v, got %v", want, body["attendances"])
+	}
+
+	// invalid attendances are not sent
+	for testNumber, attendance := range []AttendanceInput{
+		{EmployeeId: 6205887, Date: date, StartTime: "17:00", EndTime: "09:00"},
+		{EmployeeId: 6205887, Date: date, StartTime: "09:00", EndTime: "09:00"},
+		{EmployeeId: 6205887, Date: date, StartTime: "09:00", EndTime: "17:00", Break: -30},
+		{EmployeeId: 6205887, Date: date, StartTime: "9h", EndTime: "17:00"},
+	} {
+		server.mock.lastAttendancesBody = nil
+		_, err = personio.CreateAttendances([]AttendanceInput{attendance})
+		if !errors.Is(err, ErrInvalidAttendance) {
+			t.Errorf("[%d] Expected ErrInvalidAttendance, got %v", testNumber, err)
+		}
+		if server.mock.lastAttendancesBody != nil {
+			t.Errorf("[%d] Expected no request to create the attendance", testNumber)
+		}
+	}
+
+	// errors of Personio are returned as StatusError
+	_, err = personio.CreateAttendances(nil)
+	var statusErr StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != 400 {
+		t.Errorf("Expected error code 400, got %v", err)
+	}
+}
+
+func TestClient_UpdateDeleteAttendance(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	// only set fields are sent, an empty comment clears it
+	endTime, comment := "18:00", ""
+	err = personio.UpdateAttendance(81230001, AttendancePatch{EndTime: &endTime, Comment: &comment})
+	if err != nil {
+		t.Errorf("Failed to update attendance: %s", err)
+	}
+	if got := string(server.mock.lastAttendancesBody); got != `{"end_time":"18:00","comment":""}` {
+		t.Errorf("Unexpected patch body %s", got)
+	}
+
+	// invalid and empty patches are not sent
+	startTime, negative := "19:00", -5
+	for testNumber, patch := range []AttendancePatch{{StartTime: &startTime, EndTime: &endTime}, {Break: &negative}, {}} {
+		server.mock.lastAttendancesBody = nil
+		err = personio.UpdateAttendance(81230001, patch)
+		if (patch == AttendancePatch{}) != (err == nil) {
+			t.Errorf("[%d] Unexpected error %v", testNumber, err)
+		}
+		if server.mock.lastAttendancesBody != nil {
+			t.Errorf("[%d] Expected no request to update the attendance", testNumber)
+		}
+	}
+
+	// errors reported in the response body are returned with their message
+	longBreak := 720
+	err = personio.UpdateAttendance(81230001, AttendancePatch{Break: &longBreak})
+	if err == nil || !strings.Contains(err.Error(), "Break exceeds working time") {
+		t.Errorf("Expected error with Personio's message, got %v", err)
+	}
+
+	err = personio.DeleteAttendance(81230002)
+	if err != nil {
+		t.Errorf("Failed to delete attendance: %s", err)
+	}
+
+	// deleting again reports the attendance as gone
+	for _, id := range []int64{81230002, 4711} {
+		err = personio.DeleteAttendance(id)
+		var statusErr StatusError
+		if !errors.As(err, &statusErr) || statusErr.Code != 404 {
+			t.Errorf("Expected error code 404 deleting %d, got %v", id, err)
+		}
+	}
+	err = personio.UpdateAttendance(81230002, AttendancePatch{Comment: &comment})
+	var statusErr StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != 404 {
+		t.Errorf("Expected error code 404 updating deleted attendance, got %v", err)
+	}
+}
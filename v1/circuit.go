@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending a request while the circuit breaker is open
+var ErrCircuitOpen = errors.New("personio circuit breaker open")
+
+// circuitBreaker short-circuits requests after consecutive failures until a cooldown passed and a probe succeeds
+type circuitBreaker struct {
+	mutex     sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// WithCircuitBreaker fails requests with ErrCircuitOpen for cooldown after threshold consecutive failed requests
+//
+// Once the cooldown passed a single probe request is let through, closing the circuit on success.
+// Transport errors, 429 and 5xx responses count as failures, other responses as success.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(personio *Client) {
+		if threshold > 0 {
+			personio.breaker = &circuitBreaker{threshold: threshold, cooldown: cooldown}
+		}
+	}
+}
+
+// allow returns ErrCircuitOpen if a request must not be sent now
+func (c *circuitBreaker) allow(now time.Time) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.failures < c.threshold {
+		return nil
+	}
+
+	if now.Before(c.openUntil) || c.probing {
+		return ErrCircuitOpen
+	}
+
+	// half-open
+	c.probing = true
+	return nil
+}
+
+// record updates the breaker with the result of a sent request
+func (c *circuitBreaker) record(now time.Time, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.probing = false
+	if !isFailure(err) {
+		c.failures = 0
+		return
+	}
+
+	c.failures++
+	if c.failures >= c.threshold {
+		c.openUntil = now.Add(c.cooldown)
+	}
+}
+
+// isFailure returns whether err indicates Personio being unavailable
+func isFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var statusErr StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code == http.StatusTooManyRequests || statusErr.Code >= http.StatusInternalServerError
+	}
+
+	return true
+}
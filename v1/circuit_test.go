@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestClient_WithCircuitBreaker(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	cooldown := 50 * time.Millisecond
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithCircuitBreaker(2, cooldown))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+	now := makeTime("2022-09-05T12:00:00Z")
+	personio.clock = func() time.Time { return now }
+
+	server.mock.unavailable = []string{"", "", ""}
+
+	// wait advances the client's clock before the request, wantOpen expects ErrCircuitOpen, wantOk expects success
+	steps := []struct {
+		wait     time.Duration
+		wantOpen bool
+		wantOk   bool
+	}{
+		{},
+		{},
+		{wantOpen: true},
+		{wait: cooldown, wantOpen: false},
+		{wantOpen: true},
+		{wait: cooldown, wantOk: true},
+		{wantOk: true},
+	}
+
+	for stepNumber, step := range steps {
+		now = now.Add(step.wait)
+		_, err = personio.GetEmployee(6205887)
+
+		if step.wantOk {
+			if err != nil {
+				t.Errorf("[%d] Expected success, got %s", stepNumber, err)
+			}
+			continue
+		}
+
+		if err == nil {
+			t.Errorf("[%d] Expected error, got none", stepNumber)
+			continue
+		}
+
+		if errors.Is(err, ErrCircuitOpen) != step.wantOpen {
+			t.Errorf("[%d] Expected ErrCircuitOpen %t, got %s", stepNumber, step.wantOpen, err)
+		}
+	}
+
+	if len(server.mock.unavailable) != 0 {
+		t.Errorf("Expected all unavailable responses to be consumed, %d left", len(server.mock.unavailable))
+	}
+}
@@ -0,0 +1,44 @@
+package v1
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrCompanyUnknown is returned by GetCompany if nothing about the company can be derived from the Client's base URL
+var ErrCompanyUnknown = errors.New("personio company unknown")
+
+// Company is the Personio company behind the credentials, fields are empty if unknown
+type Company struct {
+	Id        int64
+	Name      string
+	Subdomain string
+}
+
+// companyHostSuffixes are the domains company subdomains of Personio are hosted under
+var companyHostSuffixes = []string{".personio.de", ".personio.com"}
+
+// GetCompany returns the company the Client is configured for
+//
+// Personio's API v1 exposes no company endpoint, so only the subdomain is derived from a base URL like
+// https://example.personio.de; Id and Name remain empty. The shared API host (DefaultBaseUrl) reveals nothing about
+// the company and fails with ErrCompanyUnknown. No request is sent, the result is the same for the Client's lifetime.
+func (personio *Client) GetCompany() (*Company, error) {
+
+	baseUrl, err := url.Parse(personio.baseUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	host := strings.ToLower(baseUrl.Hostname())
+	for _, suffix := range companyHostSuffixes {
+		subdomain := strings.TrimSuffix(host, suffix)
+		if subdomain == host || subdomain == "" || subdomain == "api" || strings.Contains(subdomain, ".") {
+			continue
+		}
+		return &Company{Subdomain: subdomain}, nil
+	}
+
+	return nil, ErrCompanyUnknown
+}
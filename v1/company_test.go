@@ -0,0 +1,47 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestClient_GetCompany(t *testing.T) {
+
+	testCases := []struct {
+		baseUrl       string
+		wantSubdomain string
+		wantErr       error
+	}{
+		{baseUrl: "", wantErr: ErrCompanyUnknown},
+		{baseUrl: "https://api.personio.de/v1", wantErr: ErrCompanyUnknown},
+		{baseUrl: "https://giantswarm.personio.de/api/v1", wantSubdomain: "giantswarm"},
+		{baseUrl: "https://GiantSwarm.personio.com:443", wantSubdomain: "giantswarm"},
+		{baseUrl: "https://eu.api.personio.de/v1", wantErr: ErrCompanyUnknown},
+		{baseUrl: "http://localhost:8080", wantErr: ErrCompanyUnknown},
+	}
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	for testCaseNumber, testCase := range testCases {
+		personio, err := NewClient(context.TODO(), testCase.baseUrl, personioCredentials)
+		if err != nil {
+			t.Errorf("[%d] Failed to create Personio API v1 client: %s", testCaseNumber, err)
+			continue
+		}
+
+		company, err := personio.GetCompany()
+		if testCase.wantErr != nil {
+			if !errors.Is(err, testCase.wantErr) {
+				t.Errorf("[%d] Expected error %v, got %v and %v", testCaseNumber, testCase.wantErr, company, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%d] Failed to get company: %s", testCaseNumber, err)
+			continue
+		}
+		if company.Subdomain != testCase.wantSubdomain || company.Id != 0 || company.Name != "" {
+			t.Errorf("[%d] Expected subdomain %s only, got %+v", testCaseNumber, testCase.wantSubdomain, *company)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package v1
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// maskedValue replaces secrets in debug output
+const maskedValue = "***"
+
+// WithDebugWriter dumps every request line and headers as well as every response status and body to w
+//
+// Authorization headers and access tokens are masked, response bodies are omitted when redaction is enabled
+func WithDebugWriter(w io.Writer) Option {
+	return func(personio *Client) {
+		personio.debugWriter = w
+	}
+}
+
+// DefaultLastRawResponseLimit is the number of bytes kept by WithLastRawResponse unless set otherwise
+const DefaultLastRawResponseLimit = 1 << 20
+
+// WithLastRawResponse makes the Client keep the body of the last response it received, see LastRawResponse
+//
+// Bodies are truncated to maxBytes (DefaultLastRawResponseLimit if not positive). Like WithDebugWriter, responses of
+// authentication requests are never kept and nothing is kept when redaction is enabled.
+func WithLastRawResponse(maxBytes int) Option {
+	return func(personio *Client) {
+		if maxBytes <= 0 {
+			maxBytes = DefaultLastRawResponseLimit
+		}
+		personio.lastRawLimit = maxBytes
+	}
+}
+
+// LastRawResponse returns a copy of the last response body received, e.g. to reproduce bugs against it
+//
+// It returns nil unless enabled via WithLastRawResponse. With concurrent requests it's the body of whichever response
+// completed last.
+func (personio *Client) LastRawResponse() []byte {
+	personio.lastRawMutex.Lock()
+	defer personio.lastRawMutex.Unlock()
+
+	if personio.lastRaw == nil {
+		return nil
+	}
+	return append([]byte{}, personio.lastRaw...)
+}
+
+// recordRawResponse keeps body as the last raw response, if enabled
+func (personio *Client) recordRawResponse(request *http.Request, body []byte) {
+	if personio.lastRawLimit == 0 || personio.redact || strings.HasSuffix(request.URL.Path, personio.authPath) {
+		return
+	}
+
+	if len(body) > personio.lastRawLimit {
+		body = body[:personio.lastRawLimit]
+	}
+
+	personio.lastRawMutex.Lock()
+	defer personio.lastRawMutex.Unlock()
+	personio.lastRaw = append(personio.lastRaw[:0], body...)
+}
+
+// dumpHeader writes the header sorted by name with secrets masked
+func dumpHeader(w io.Writer, prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range header[name] {
+			if strings.EqualFold(name, "Authorization") {
+				value = "Bearer " + maskedValue
+			}
+			_, _ = fmt.Fprintf(w, "%s %s: %s\n", prefix, name, value)
+		}
+	}
+}
+
+// redactCredentials returns uri with the client secret masked, as authentication requests not using POST carry the
+// credentials in the query
+func redactCredentials(uri url.URL) url.URL {
+	query := uri.Query()
+	if query.Get("client_secret") != "" {
+		query.Set("client_secret", maskedValue)
+		uri.RawQuery = query.Encode()
+	}
+	return uri
+}
+
+// dumpRequest writes the request line and headers to the debug writer, if any
+func (personio *Client) dumpRequest(request *http.Request) {
+	if personio.debugWriter == nil {
+		return
+	}
+
+	uri := redactCredentials(*request.URL)
+	_, _ = fmt.Fprintf(personio.debugWriter, "> %s %s %s\n", request.Method, uri.RequestURI(), request.Proto)
+	_, _ = fmt.Fprintf(personio.debugWriter, "> Host: %s\n", request.URL.Host)
+	dumpHeader(personio.debugWriter, ">", request.Header)
+	_, _ = fmt.Fprintln(personio.debugWriter, ">")
+}
+
+// dumpResponse writes the response status and body to the debug writer, if any
+func (personio *Client) dumpResponse(request *http.Request, response *http.Response, body []byte) {
+	if personio.debugWriter == nil {
+		return
+	}
+
+	_, _ = fmt.Fprintf(personio.debugWriter, "< %s %s\n", response.Proto, response.Status)
+	if personio.redact {
+		_, _ = fmt.Fprintf(personio.debugWriter, "< %s\n", redactedMessage)
+	} else if strings.HasSuffix(request.URL.Path, personio.authPath) {
+		// contains the access token
+		_, _ = fmt.Fprintf(personio.debugWriter, "< %s\n", maskedValue)
+	} else {
+		_, _ = fmt.Fprintf(personio.debugWriter, "< %s\n", body)
+	}
+	_, _ = fmt.Fprintln(personio.debugWriter, "<")
+}
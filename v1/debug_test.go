@@ -0,0 +1,114 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestClient_WithDebugWriter(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	for _, redact := range []bool{false, true} {
+		server.mock.lastToken = ""
+
+		var debug bytes.Buffer
+		personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+		personio, err := NewClient(ContextWithRequestId(context.TODO(), "debug"), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithDebugWriter(&debug), WithRedaction(redact))
+		if err != nil {
+			t.Errorf("Failed to create Personio API v1 client: %s", err)
+			return
+		}
+
+		_, err = personio.GetEmployee(6205887)
+		if err != nil {
+			t.Errorf("[redact=%t] Failed to query employee: %s", redact, err)
+			continue
+		}
+
+		dump := debug.String()
+		for _, want := range []string{"> POST /auth HTTP/1.1", "> GET /company/employees/6205887 HTTP/1.1", "> Authorization: Bearer ***", "< HTTP/1.1 200 OK"} {
+			if !strings.Contains(dump, want) {
+				t.Errorf("[redact=%t] Expected debug output to contain \"%s\", got:\n%s", redact, want, dump)
+			}
+		}
+
+		// access tokens and client secret must never be dumped
+		for _, secret := range []string{"ghi", "def"} {
+			if strings.Contains(dump, secret) {
+				t.Errorf("[redact=%t] Expected debug output to mask \"%s\", got:\n%s", redact, secret, dump)
+			}
+		}
+
+		if strings.Contains(dump, "gonzo@giantswarm.io") != !redact {
+			t.Errorf("[redact=%t] Unexpected response body in debug output:\n%s", redact, dump)
+		}
+	}
+}
+
+func TestClient_WithLastRawResponse(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+	server.mock.uniqueTokens = true
+
+	testCases := []struct {
+		opts     []Option
+		wantSize int
+	}{
+		{opts: nil, wantSize: -1},
+		{opts: []Option{WithLastRawResponse(0)}},
+		{opts: []Option{WithLastRawResponse(16)}, wantSize: 16},
+		{opts: []Option{WithLastRawResponse(0), WithRedaction(true)}, wantSize: -1},
+	}
+
+	for testNumber, testCase := range testCases {
+		personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+		personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, testCase.opts...)
+		if err != nil {
+			t.Errorf("[%d] Failed to create Personio API v1 client: %s", testNumber, err)
+			continue
+		}
+
+		_, err = personio.GetEmployee(6205887)
+		if err != nil {
+			t.Errorf("[%d] Failed to query employee: %s", testNumber, err)
+			continue
+		}
+
+		raw := personio.LastRawResponse()
+		switch {
+		case testCase.wantSize < 0:
+			if raw != nil {
+				t.Errorf("[%d] Expected no raw response, got %s", testNumber, raw)
+			}
+		case testCase.wantSize > 0:
+			if len(raw) != testCase.wantSize {
+				t.Errorf("[%d] Expected %d bytes, got %d", testNumber, testCase.wantSize, len(raw))
+			}
+		default:
+			// the employee's body rather than the preceding authentication
+			if !strings.Contains(string(raw), "gonzo@giantswarm.io") {
+				t.Errorf("[%d] Expected employee response body, got %s", testNumber, raw)
+			}
+		}
+	}
+}
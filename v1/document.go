@@ -0,0 +1,56 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Document is the metadata of a single document stored for an employee
+type Document struct {
+	Id       int64  `json:"id"`
+	Title    string `json:"title"`
+	Filename string `json:"file_name"`
+	Category struct {
+		Type       string `json:"type"`
+		Attributes struct {
+			Id   int64  `json:"id"`
+			Name string `json:"name"`
+		} `json:"attributes"`
+	} `json:"category"`
+	EmployeeId int64     `json:"employee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// documentContainer is the typed object returned for documents by Personio
+type documentContainer struct {
+	Type       string   `json:"type"`
+	Attributes Document `json:"attributes"`
+}
+
+// GetEmployeeDocuments returns the metadata of all documents stored for the specified employee
+func (personio *Client) GetEmployeeDocuments(employeeId int64) ([]Document, error) {
+
+	query := url.Values{}
+	query.Add("employee_id", strconv.FormatInt(employeeId, 10))
+	results, count, err := personio.getPages("/company/documents", query, 0, intMax)
+	if err != nil {
+		return nil, err
+	}
+
+	// unpack Document elements
+	documents := make([]Document, 0, count)
+	for i := range results {
+		for j := range results[i].Data {
+			var result documentContainer
+			err = json.Unmarshal(results[i].Data[j], &result)
+			if err != nil {
+				return nil, err
+			}
+			documents = append(documents, result.Attributes)
+		}
+	}
+
+	return documents, nil
+}
@@ -0,0 +1,66 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestClient_GetEmployeeDocuments(t *testing.T) {
+
+	testCases := []struct {
+		employeeId int64
+		wantIds    []int64
+	}{
+		{employeeId: 6205887, wantIds: []int64{170000001, 170000002}},
+		{employeeId: 7161253, wantIds: []int64{170000003}},
+		{employeeId: 0xdeadbeef, wantIds: []int64{}},
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	for testCaseNumber, testCase := range testCases {
+
+		documents, err := personio.GetEmployeeDocuments(testCase.employeeId)
+		if err != nil {
+			t.Errorf("[%d] Failed to query documents: %s", testCaseNumber, err)
+			continue
+		}
+
+		if documents == nil || len(documents) != len(testCase.wantIds) {
+			t.Errorf("[%d] Expected %d documents, got %v", testCaseNumber, len(testCase.wantIds), documents)
+			continue
+		}
+
+		for i, wantId := range testCase.wantIds {
+			if documents[i].Id != wantId {
+				t.Errorf("[%d] Expected document %d, got %d", testCaseNumber, wantId, documents[i].Id)
+			}
+		}
+	}
+
+	documents, err := personio.GetEmployeeDocuments(6205887)
+	if err != nil {
+		t.Errorf("Failed to query documents: %s", err)
+		return
+	}
+	contract := documents[0]
+	if contract.Filename != "contract-el-gonzo.pdf" || contract.Category.Attributes.Name != "Contracts" || !contract.CreatedAt.Equal(makeTime("2022-01-10T09:15:00+01:00")) {
+		t.Errorf("Expected contract metadata, got %+v", contract)
+	}
+}
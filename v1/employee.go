@@ -0,0 +1,827 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hashEmployees computes a stable hash of the employees' attribute values independent of their order
+func hashEmployees(employees []*Employee) (string, error) {
+
+	type hashedEmployee struct {
+		Type       string                 `json:"type"`
+		Attributes map[string]interface{} `json:"attributes"`
+	}
+
+	hashed := make([]hashedEmployee, len(employees))
+	for i, employee := range employees {
+		values := make(map[string]interface{}, len(employee.Attributes))
+		for key, attr := range employee.Attributes {
+			values[key] = attr.Value
+		}
+		hashed[i] = hashedEmployee{Type: employee.Type, Attributes: values}
+	}
+
+	// encoding/json sorts map keys, the elements still need a deterministic order
+	serialized := make([]string, len(hashed))
+	for i := range hashed {
+		data, err := json.Marshal(hashed[i])
+		if err != nil {
+			return "", err
+		}
+		serialized[i] = string(data)
+	}
+	sort.Strings(serialized)
+
+	hash := sha256.New()
+	for _, data := range serialized {
+		hash.Write([]byte(data))
+		hash.Write([]byte{'\n'})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// EmployeesChanged fetches all employees and reports whether their hash differs from previousHash
+//
+// The returned currentHash is stable across runs as long as no attribute value changes
+func (personio *Client) EmployeesChanged(previousHash string) (changed bool, currentHash string, employees []*Employee, err error) {
+
+	employees, err = personio.getEmployees(context.Background(), nil)
+	if err != nil {
+		return false, "", nil, err
+	}
+
+	currentHash, err = hashEmployees(employees)
+	if err != nil {
+		return false, "", nil, err
+	}
+
+	return currentHash != previousHash, currentHash, employees, nil
+}
+
+// GetEmployeesHiredBetween returns the employees whose hire_date lies between start and end (both inclusive)
+//
+// Dates are compared as calendar days, hire dates in the offset sent by Personio and start and end in their own
+// locations. Employees without a hire_date are excluded, their count is reported to the Logger
+func (personio *Client) GetEmployeesHiredBetween(start time.Time, end time.Time) ([]*Employee, error) {
+
+	employees, err := personio.getEmployees(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	startDay, endDay := calendarDay(start), calendarDay(end)
+	hired := make([]*Employee, 0)
+	missing := 0
+	for _, employee := range employees {
+		hireDate := employee.GetTimeAttribute("hire_date")
+		if hireDate == nil {
+			missing++
+			continue
+		}
+
+		if hireDay := calendarDay(*hireDate); !hireDay.Before(startDay) && !hireDay.After(endDay) {
+			hired = append(hired, employee)
+		}
+	}
+
+	if missing > 0 {
+		personio.logf("personio: excluded %d employees without hire_date", missing)
+	}
+
+	return hired, nil
+}
+
+// calendarDay returns the calendar day of t in its own location as midnight UTC, e.g. to compare dates sent with
+// different offsets
+func calendarDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// SyncCursor is the checkpoint of an incremental employee sync as returned by GetEmployeesSince, meant to be persisted
+//
+// UpdatedAt is the latest last_modified_at seen and Ids are the employees modified at exactly that instant, which
+// are not returned again. The zero SyncCursor starts a full sync.
+type SyncCursor struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	Ids       []int64   `json:"ids,omitempty"`
+}
+
+// updatedSinceFormat is the layout of Personio's updated_since query parameter (UTC)
+const updatedSinceFormat = "2006-01-02T15:04:05"
+
+// GetEmployeesSince returns the employees modified after the cursor together with the cursor to pass next time
+//
+// Employees are selected by their last_modified_at attribute. Those without one are only returned by a full sync,
+// later syncs skip them and report their count to the Logger. The cursor is returned unchanged if nothing changed.
+func (personio *Client) GetEmployeesSince(cursor SyncCursor) ([]*Employee, SyncCursor, error) {
+
+	params := url.Values{}
+	if !cursor.UpdatedAt.IsZero() {
+		params.Set("updated_since", cursor.UpdatedAt.UTC().Format(updatedSinceFormat))
+	}
+
+	employees, err := personio.getEmployees(context.Background(), params)
+	if err != nil {
+		return nil, cursor, err
+	}
+
+	seen := make(map[int64]bool, len(cursor.Ids))
+	for _, id := range cursor.Ids {
+		seen[id] = true
+	}
+
+	// updated_since is only accurate to the second, the precise selection happens here
+	changed := make([]*Employee, 0, len(employees))
+	next := SyncCursor{UpdatedAt: cursor.UpdatedAt, Ids: append([]int64(nil), cursor.Ids...)}
+	missing := 0
+	for _, employee := range employees {
+		// the precise timestamp, never normalized to a date
+		modifiedAt := employee.attribute("last_modified_at").GetTimeValue()
+		if modifiedAt == nil {
+			if cursor.UpdatedAt.IsZero() {
+				changed = append(changed, employee)
+			} else {
+				missing++
+			}
+			continue
+		}
+
+		id := employee.GetIntAttribute("id")
+		if modifiedAt.Before(cursor.UpdatedAt) || (modifiedAt.Equal(cursor.UpdatedAt) && id != nil && seen[*id]) {
+			continue
+		}
+		changed = append(changed, employee)
+
+		switch {
+		case modifiedAt.After(next.UpdatedAt):
+			next = SyncCursor{UpdatedAt: modifiedAt.UTC()}
+			fallthrough
+		case modifiedAt.Equal(next.UpdatedAt):
+			if id != nil {
+				next.Ids = append(next.Ids, *id)
+			}
+		}
+	}
+
+	if missing > 0 {
+		personio.logf("personio: skipped %d employees without last_modified_at", missing)
+	}
+
+	sort.Slice(next.Ids, func(i, j int) bool { return next.Ids[i] < next.Ids[j] })
+	return changed, next, nil
+}
+
+// TerminationDate returns the employee's termination_date or contract_end_date, nil if neither is set (ie. active employees)
+func (e *Employee) TerminationDate() *time.Time {
+	terminationDate := e.GetTimeAttribute("termination_date")
+	if terminationDate == nil {
+		terminationDate = e.GetTimeAttribute("contract_end_date")
+	}
+	return terminationDate
+}
+
+// employedOn returns whether the employee was hired on or before date and whether the employee was terminated on or before date
+func (e *Employee) employedOn(date time.Time) (hired bool, terminated bool) {
+	hireDate := e.GetTimeAttribute("hire_date")
+	terminationDate := e.TerminationDate()
+	return hireDate != nil && !hireDate.After(date), terminationDate != nil && !terminationDate.After(date)
+}
+
+// HeadcountOn returns the number of employees hired on or before date and not terminated on or before date
+func HeadcountOn(employees []*Employee, date time.Time) int {
+	count := 0
+	for _, employee := range employees {
+		if hired, terminated := employee.employedOn(date); hired && !terminated {
+			count++
+		}
+	}
+	return count
+}
+
+// PartitionEmployeesByActive splits the employees into those active, those terminated and all others as of asOf in one pass
+//
+// Active employees are counted by HeadcountOn, terminated ones have a termination (or contract end) date on or before
+// asOf. Employees not hired by asOf or without hire_date are in other unless they are terminated, so every employee
+// ends up in exactly one bucket.
+func PartitionEmployeesByActive(employees []*Employee, asOf time.Time) (active []*Employee, terminated []*Employee, other []*Employee) {
+	active, terminated, other = make([]*Employee, 0), make([]*Employee, 0), make([]*Employee, 0)
+	for _, employee := range employees {
+		hired, isTerminated := employee.employedOn(asOf)
+		if isTerminated {
+			terminated = append(terminated, employee)
+		} else if hired {
+			active = append(active, employee)
+		} else {
+			other = append(other, employee)
+		}
+	}
+	return active, terminated, other
+}
+
+// FilterEmployees returns the employees pred returns true for, keeping their order
+//
+// Combined with the typed attribute getters this covers filtering by arbitrary attributes, e.g. status or department.
+// The result is empty but not nil if no employee matches.
+func FilterEmployees(employees []*Employee, pred func(*Employee) bool) []*Employee {
+	matched := make([]*Employee, 0)
+	for _, employee := range employees {
+		if pred(employee) {
+			matched = append(matched, employee)
+		}
+	}
+	return matched
+}
+
+// ProfileURL returns the URL of the employee's profile in the Personio web app or "" if the employee has no ID
+//
+// baseWebURL is the company's web app URL, e.g. https://example.personio.de. It differs from the API base URL and
+// can't be derived from it, so it has to be provided.
+func (e *Employee) ProfileURL(baseWebURL string) string {
+	id := e.GetIntAttribute("id")
+	if id == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/staff/details/%d", strings.TrimRight(baseWebURL, "/"), *id)
+}
+
+// EmployeeWithManager is an Employee with its supervisor resolved
+type EmployeeWithManager struct {
+	*Employee
+	Manager *Employee
+}
+
+// SupervisorID returns the ID of the employee's supervisor or nil if there is none
+//
+// Depending on the account Personio returns the supervisor as nested employee object or as bare ID, both are handled.
+func (e *Employee) SupervisorID() *int64 {
+	return e.EmployeeRefId("supervisor")
+}
+
+// resolveManagers resolves the supervisors of the specified employees from the very same slice
+func resolveManagers(employees []*Employee) []*EmployeeWithManager {
+
+	employeesById := make(map[int64]*Employee, len(employees))
+	for _, employee := range employees {
+		if id := employee.GetIntAttribute("id"); id != nil {
+			employeesById[*id] = employee
+		}
+	}
+
+	resolved := make([]*EmployeeWithManager, len(employees))
+	for i, employee := range employees {
+		resolved[i] = &EmployeeWithManager{Employee: employee}
+		if supervisorId := employee.SupervisorID(); supervisorId != nil {
+			// supervisors outside the fetched employees (e.g. inactive) remain nil
+			resolved[i].Manager = employeesById[*supervisorId]
+		}
+	}
+
+	return resolved
+}
+
+// GetEmployeesWithManagers returns all employees with their supervisors resolved without additional requests
+func (personio *Client) GetEmployeesWithManagers() ([]*EmployeeWithManager, error) {
+
+	employees, err := personio.GetEmployees()
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveManagers(employees), nil
+}
+
+// employeePatchBody is the request body of PATCH /company/employees/{id}
+type employeePatchBody struct {
+	Employee map[string]interface{} `json:"employee"`
+}
+
+// diffEmployeeAttributes returns the desired attribute values differing from the employee's current values
+//
+// Values are compared by their JSON representation, custom attributes (dynamic_*) are nested in custom_attributes
+func diffEmployeeAttributes(employee *Employee, desired map[string]interface{}) (map[string]interface{}, error) {
+
+	// normalize desired values to the types current values were decoded to
+	data, err := json.Marshal(desired)
+	if err != nil {
+		return nil, err
+	}
+	var normalized map[string]interface{}
+	err = json.Unmarshal(data, &normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := map[string]interface{}{}
+	customAttributes := map[string]interface{}{}
+	for key, value := range normalized {
+		current, ok := employee.Attributes[key]
+		if ok && reflect.DeepEqual(current.Value, value) {
+			continue
+		}
+
+		if strings.HasPrefix(key, "dynamic_") {
+			customAttributes[key] = desired[key]
+		} else {
+			changed[key] = desired[key]
+		}
+	}
+
+	if len(customAttributes) > 0 {
+		changed["custom_attributes"] = customAttributes
+	}
+
+	return changed, nil
+}
+
+// SyncEmployee updates the employee's attributes differing from desired and returns the refreshed employee
+//
+// No update is sent if all desired values already match
+func (personio *Client) SyncEmployee(id int64, desired map[string]interface{}) (*Employee, error) {
+
+	employee, err := personio.GetEmployee(id)
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := diffEmployeeAttributes(employee, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changed) == 0 {
+		return employee, nil
+	}
+
+	requestBody, err := json.Marshal(employeePatchBody{Employee: changed})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPatch, personio.baseUrl+fmt.Sprintf("/company/employees/%d", id), bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = personio.doRequestJson(req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return personio.GetEmployee(id)
+}
+
+// nestedId returns the ID of the nested object (e.g. office or department) of the specified attribute or nil
+func (e *Employee) nestedId(key string) *int64 {
+	value, ok := e.GetMapAttribute(key)["id"].(float64)
+	if !ok {
+		return nil
+	}
+	id := int64(value)
+	return &id
+}
+
+// GetEmployeesByOffice returns the employees of the specified office
+//
+// Personio doesn't support filtering by office, so all employees are fetched and filtered client-side
+func (personio *Client) GetEmployeesByOffice(officeId int64) ([]*Employee, error) {
+
+	employees, err := personio.GetEmployees()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]*Employee, 0)
+	for _, employee := range employees {
+		if id := employee.nestedId("office"); id != nil && *id == officeId {
+			matched = append(matched, employee)
+		}
+	}
+
+	return matched, nil
+}
+
+// NamedRef is a reference to a nested object (e.g. team) by ID and name
+type NamedRef struct {
+	Id   int64
+	Name string
+}
+
+// Team returns a reference to the employee's team or nil if the employee has none
+//
+// Teams are independent of departments in Personio
+func (e *Employee) Team() *NamedRef {
+	id := e.nestedId("team")
+	if id == nil {
+		return nil
+	}
+	name, _ := e.GetMapAttribute("team")["name"].(string)
+	return &NamedRef{Id: *id, Name: name}
+}
+
+// GetEmployeesByTeam returns the employees of the specified team
+//
+// Personio doesn't support filtering by team, so all employees are fetched and filtered client-side
+func (personio *Client) GetEmployeesByTeam(teamId int64) ([]*Employee, error) {
+
+	employees, err := personio.GetEmployees()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]*Employee, 0)
+	for _, employee := range employees {
+		if team := employee.Team(); team != nil && team.Id == teamId {
+			matched = append(matched, employee)
+		}
+	}
+
+	return matched, nil
+}
+
+// Email returns the employee's email address or an error if it is missing or malformed
+func (e *Employee) Email() (string, error) {
+	email := e.GetStringAttribute("email")
+	if email == nil || strings.TrimSpace(*email) == "" {
+		return "", errors.New("employee has no email")
+	}
+
+	address, err := mail.ParseAddress(*email)
+	if err != nil {
+		return "", fmt.Errorf("invalid employee email: %w", err)
+	}
+
+	// display names like "Name <name@example.com>" are no plain email address
+	if address.Address != strings.TrimSpace(*email) {
+		return "", errors.New("invalid employee email: not a plain address")
+	}
+
+	return address.Address, nil
+}
+
+// stableValue returns the attribute's value coerced according to its type for MarshalStable
+func (a *Attribute) stableValue() interface{} {
+	switch a.Type {
+	case "integer":
+		if value := a.GetIntValue(); value != nil {
+			return *value
+		}
+	case "decimal":
+		if value := a.GetFloatValue(); value != nil {
+			// keep decimals distinguishable from integers
+			number := strconv.FormatFloat(*value, 'f', -1, 64)
+			if !strings.Contains(number, ".") {
+				number += ".0"
+			}
+			return json.Number(number)
+		}
+	case "date":
+		if value := a.GetTimeValue(); value != nil {
+			return value.Format(time.RFC3339)
+		}
+	case "tags":
+		if value := a.GetTagValues(); value != nil {
+			return value
+		}
+	case "standard":
+		nested, _ := a.Value.(map[string]interface{})
+		if nestedAttributes, ok := nested["attributes"].(map[string]interface{}); ok {
+			return nestedAttributes
+		}
+	}
+	return a.Value
+}
+
+// MarshalStable serializes the employee as flat JSON object mapping attribute keys to coerced values, sorted by key
+//
+// Integers and decimals are encoded as numbers (decimals always with fraction), dates as RFC 3339 strings, tags as string
+// arrays and nested objects (e.g. office) as their attributes. Labels, universal IDs and the nested objects' types are dropped.
+func (e *Employee) MarshalStable() ([]byte, error) {
+	values := make(map[string]interface{}, len(e.Attributes))
+	for key := range e.Attributes {
+		attr := e.Attributes[key]
+		values[key] = attr.stableValue()
+	}
+	return json.Marshal(values)
+}
+
+// UnmarshalStable replaces the employee's attributes with those serialized by MarshalStable
+//
+// Attribute types are inferred from the values: numbers without fraction become integers, other numbers decimals,
+// RFC 3339 strings dates, non-empty string arrays tags and everything else standard attributes.
+func (e *Employee) UnmarshalStable(data []byte) error {
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var values map[string]interface{}
+	err := decoder.Decode(&values)
+	if err != nil {
+		return err
+	}
+
+	attributes := make(map[string]Attribute, len(values))
+	for key, value := range values {
+		attributes[key] = stableAttribute(value)
+	}
+
+	e.Type = "Employee"
+	e.Attributes = attributes
+	return nil
+}
+
+// stableAttribute infers the Attribute of a value decoded from MarshalStable output
+func stableAttribute(value interface{}) Attribute {
+	switch value.(type) {
+	case json.Number:
+		number := value.(json.Number)
+		attrType := "integer"
+		if strings.ContainsAny(number.String(), ".eE") {
+			attrType = "decimal"
+		}
+		return Attribute{Type: attrType, Value: denumber(number)}
+	case string:
+		if _, err := time.Parse(time.RFC3339, value.(string)); err == nil {
+			return Attribute{Type: "date", Value: value}
+		}
+	case []interface{}:
+		tags := value.([]interface{})
+		if len(tags) == 0 {
+			break
+		}
+		for _, tag := range tags {
+			if _, ok := tag.(string); !ok {
+				return Attribute{Type: "standard", Value: denumber(value)}
+			}
+		}
+		return Attribute{Type: "tags", Value: tags}
+	case map[string]interface{}:
+		return Attribute{Type: "standard", Value: map[string]interface{}{"attributes": denumber(value)}}
+	}
+	return Attribute{Type: "standard", Value: value}
+}
+
+// denumber recursively converts json.Number values to float64 as produced by decoding without UseNumber
+func denumber(value interface{}) interface{} {
+	switch value.(type) {
+	case json.Number:
+		number, _ := value.(json.Number).Float64()
+		return number
+	case []interface{}:
+		elements := value.([]interface{})
+		for i := range elements {
+			elements[i] = denumber(elements[i])
+		}
+	case map[string]interface{}:
+		object := value.(map[string]interface{})
+		for key := range object {
+			object[key] = denumber(object[key])
+		}
+	}
+	return value
+}
+
+// LabeledValue is an attribute's raw value together with its stable key and human-readable label
+type LabeledValue struct {
+	Key   string
+	Label string
+	Value interface{}
+}
+
+// LabeledValues returns the employee's attribute values with their labels in the order Personio sent them
+//
+// Attributes without label are labeled with their key, e.g. to use the labels as CSV headers
+func (e *Employee) LabeledValues() []LabeledValue {
+	if e == nil {
+		return nil
+	}
+
+	keys := e.OrderedKeys()
+	values := make([]LabeledValue, 0, len(keys))
+	for _, key := range keys {
+		attribute := e.Attributes[key]
+		label := attribute.Label
+		if label == "" {
+			label = key
+		}
+		values = append(values, LabeledValue{Key: key, Label: label, Value: attribute.Value})
+	}
+	return values
+}
+
+// DefaultCostCenterKey is the attribute CostCenters reads
+const DefaultCostCenterKey = "cost_centers"
+
+// CostCenters returns the names of the employee's cost centers read from the DefaultCostCenterKey attribute
+func (e *Employee) CostCenters() []string {
+	return e.CostCentersFromAttribute(DefaultCostCenterKey)
+}
+
+// CostCentersFromAttribute returns the cost center names stored in the specified attribute, e.g. a custom one
+//
+// Values may be a single name, a list of names or a list of (typed) cost center objects. An empty slice is returned
+// if the attribute is unset.
+func (e *Employee) CostCentersFromAttribute(key string) []string {
+
+	costCenters := []string{}
+	if e == nil {
+		return costCenters
+	}
+	attribute := e.attribute(key)
+	if attribute == nil {
+		return costCenters
+	}
+
+	var elements []interface{}
+	switch value := attribute.Value.(type) {
+	case []interface{}:
+		elements = value
+	case string, map[string]interface{}:
+		elements = []interface{}{value}
+	}
+
+	for _, element := range elements {
+		if name := strings.TrimSpace(tagName(element)); name != "" {
+			costCenters = append(costCenters, name)
+		}
+	}
+	return costCenters
+}
+
+// DisplayName returns the employee's preferred_name, falling back to "first_name last_name" and finally to the email
+func (e *Employee) DisplayName() string {
+	if preferred := e.GetStringAttribute("preferred_name"); preferred != nil && strings.TrimSpace(*preferred) != "" {
+		return strings.TrimSpace(*preferred)
+	}
+
+	var names []string
+	for _, key := range []string{"first_name", "last_name"} {
+		if name := e.GetStringAttribute(key); name != nil && strings.TrimSpace(*name) != "" {
+			names = append(names, strings.TrimSpace(*name))
+		}
+	}
+	if len(names) > 0 {
+		return strings.Join(names, " ")
+	}
+
+	if email := e.GetStringAttribute("email"); email != nil {
+		return strings.TrimSpace(*email)
+	}
+
+	return ""
+}
+
+// activeEmployees returns the employees with status "active"
+func activeEmployees(employees []*Employee) []*Employee {
+	active := make([]*Employee, 0, len(employees))
+	for _, employee := range employees {
+		if status := employee.GetStringAttribute("status"); status != nil && *status == "active" {
+			active = append(active, employee)
+		}
+	}
+	return active
+}
+
+// HasProfilePicture returns whether a profile picture exists for the specified employee without downloading it
+func (personio *Client) HasProfilePicture(id int64) (bool, error) {
+
+	req, err := http.NewRequest(http.MethodHead, fmt.Sprintf("%s/company/employees/%d/profile-picture", personio.baseUrl, id), nil)
+	if err != nil {
+		return false, err
+	}
+
+	_, _, err = personio.doRequest(req, true)
+
+	var statusErr StatusError
+	if errors.As(err, &statusErr) && statusErr.Code == http.StatusNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// refId returns the employee ID referenced by an attribute value or nil if the value is no reference
+//
+// References may be bare IDs (numbers or numeric strings), objects with an "id" or (typed) objects with an "id" attribute
+func refId(value interface{}) *int64 {
+	switch value.(type) {
+	case float64:
+		id := int64(value.(float64))
+		return &id
+	case string:
+		id, err := strconv.ParseInt(strings.TrimSpace(value.(string)), 10, 64)
+		if err != nil {
+			return nil
+		}
+		return &id
+	case map[string]interface{}:
+		object := value.(map[string]interface{})
+		if attributes, ok := object["attributes"].(map[string]interface{}); ok {
+			object = attributes
+		}
+		id := object["id"]
+		// nested attributes wrap their values, e.g. {"label": "ID", "value": 123}
+		if nested, ok := id.(map[string]interface{}); ok {
+			id = nested["value"]
+		}
+		if _, ok := id.(map[string]interface{}); ok {
+			return nil
+		}
+		return refId(id)
+	}
+	return nil
+}
+
+// EmployeeRefId returns the ID of the employee referenced by the specified attribute (e.g. "supervisor" or a custom
+// "mentor" attribute) or nil if the attribute is unset or holds no reference
+func (e *Employee) EmployeeRefId(key string) *int64 {
+	if e == nil {
+		return nil
+	}
+	attribute := e.attribute(key)
+	if attribute == nil {
+		return nil
+	}
+	return refId(attribute.Value)
+}
+
+// ResolveEmployeeRefAttribute fetches the employee referenced by the specified attribute, nil if it is unset
+//
+// Referenced employees are cached for the lifetime of the Client, so resolving the same reference again sends no request.
+func (personio *Client) ResolveEmployeeRefAttribute(e *Employee, key string) (*Employee, error) {
+
+	id := e.EmployeeRefId(key)
+	if id == nil {
+		return nil, nil
+	}
+
+	personio.employeeRefsMutex.Lock()
+	cached, ok := personio.employeeRefs[*id]
+	personio.employeeRefsMutex.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	employee, err := personio.GetEmployee(*id)
+	if err != nil {
+		return nil, err
+	}
+
+	personio.employeeRefsMutex.Lock()
+	if personio.employeeRefs == nil {
+		personio.employeeRefs = map[int64]*Employee{}
+	}
+	personio.employeeRefs[*id] = employee
+	personio.employeeRefsMutex.Unlock()
+
+	return employee, nil
+}
+
+// ResolveEmployeeRefChain follows the specified reference attribute recursively, e.g. the chain of supervisors
+//
+// The referenced employees are returned in order, at most maxDepth of them. The chain ends early at an unset reference
+// or at an employee already part of the chain (including e itself).
+func (personio *Client) ResolveEmployeeRefChain(e *Employee, key string, maxDepth int) ([]*Employee, error) {
+
+	chain := make([]*Employee, 0)
+	seen := map[int64]bool{}
+	if id := e.GetIntAttribute("id"); id != nil {
+		seen[*id] = true
+	}
+
+	current := e
+	for len(chain) < maxDepth {
+		id := current.EmployeeRefId(key)
+		if id == nil || seen[*id] {
+			break
+		}
+		seen[*id] = true
+
+		next, err := personio.ResolveEmployeeRefAttribute(current, key)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, next)
+		current = next
+	}
+
+	return chain, nil
+}
@@ -0,0 +1,1051 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_EmployeesChanged(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	changed, hash, employees, err := personio.EmployeesChanged("")
+	if err != nil {
+		t.Errorf("Failed to check employees for changes: %s", err)
+		return
+	}
+	if !changed || hash == "" || len(employees) != 2 {
+		t.Errorf("Expected initial check to report a change with hash and 2 employees, got changed=%t hash=%s employees=%d", changed, hash, len(employees))
+	}
+
+	changed, nextHash, _, err := personio.EmployeesChanged(hash)
+	if err != nil {
+		t.Errorf("Failed to check employees for changes: %s", err)
+		return
+	}
+	if changed || nextHash != hash {
+		t.Errorf("Expected unchanged employees to keep hash %s, got changed=%t hash=%s", hash, changed, nextHash)
+	}
+
+	// order must not matter, values must
+	reversedHash, err := hashEmployees([]*Employee{employees[1], employees[0]})
+	if err != nil || reversedHash != hash {
+		t.Errorf("Expected hash to be independent of employee order, got %s (%v)", reversedHash, err)
+	}
+
+	modified := *employees[0]
+	modified.Attributes = map[string]Attribute{}
+	for key, attr := range employees[0].Attributes {
+		modified.Attributes[key] = attr
+	}
+	email := modified.Attributes["email"]
+	email.Value = "changed@giantswarm.io"
+	modified.Attributes["email"] = email
+	modifiedHash, err := hashEmployees([]*Employee{&modified, employees[1]})
+	if err != nil || modifiedHash == hash {
+		t.Errorf("Expected hash to change with an attribute value, got %s (%v)", modifiedHash, err)
+	}
+}
+
+type employeesHiredTestCase struct {
+	start   time.Time
+	end     time.Time
+	wantIds []int64
+}
+
+func TestClient_GetEmployeesHiredBetween(t *testing.T) {
+
+	employeeCases := []employeesHiredTestCase{
+		{start: makeTime("2022-01-01T00:00:00Z"), end: makeTime("2022-03-31T23:59:59Z"), wantIds: []int64{6205887}},
+		{start: makeTime("2022-01-12T00:00:00+01:00"), end: makeTime("2022-05-05T00:00:00+02:00"), wantIds: []int64{6205887, 7161253}},
+		{start: makeTime("2022-01-13T00:00:00+01:00"), end: makeTime("2022-05-04T23:59:59+02:00"), wantIds: []int64{}},
+		// hire dates are sent with offsets, Mega's as 2022-05-05T00:00:00+02:00 (2022-05-04T22:00:00Z)
+		{start: makeTime("2022-05-05T00:00:00Z"), end: makeTime("2022-05-05T00:00:00Z"), wantIds: []int64{7161253}},
+		{start: makeTime("2022-01-12T12:00:00Z"), end: makeTime("2022-01-12T12:00:00Z"), wantIds: []int64{6205887}},
+		{start: makeTime("2023-01-01T00:00:00Z"), end: makeTime("2023-12-31T00:00:00Z"), wantIds: []int64{}},
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	for testNumber, testCase := range employeeCases {
+		employees, err := personio.GetEmployeesHiredBetween(testCase.start, testCase.end)
+		if err != nil {
+			t.Errorf("[%d] Failed to query employees: %s", testNumber, err)
+			continue
+		}
+
+		if employees == nil || len(testCase.wantIds) != len(employees) {
+			t.Errorf("[%d] Expected %d employees, got %v", testNumber, len(testCase.wantIds), employees)
+			continue
+		}
+
+		for i, id := range testCase.wantIds {
+			employeeId := employees[i].GetIntAttribute("id")
+			if employeeId == nil || *employeeId != id {
+				t.Errorf("[%d] Expected employee with ID %d, got %v", testNumber, id, employeeId)
+			}
+		}
+	}
+}
+
+// makeEmployee creates an employee with the specified ID and date attributes (omitted if empty)
+func makeEmployee(id int64, hireDate string, terminationDate string) *Employee {
+	employee := &Employee{Type: "Employee", AttributeContainer: AttributeContainer{Attributes: map[string]Attribute{
+		"id": {Label: "ID", Value: float64(id), Type: "integer", UniversalId: "id"},
+	}}}
+	if hireDate != "" {
+		employee.Attributes["hire_date"] = Attribute{Label: "Hire date", Value: hireDate, Type: "date", UniversalId: "hire_date"}
+	}
+	if terminationDate != "" {
+		employee.Attributes["termination_date"] = Attribute{Label: "Termination date", Value: terminationDate, Type: "date", UniversalId: "termination_date"}
+	}
+	return employee
+}
+
+type headcountTestCase struct {
+	date      time.Time
+	wantCount int
+}
+
+func TestHeadcountOn(t *testing.T) {
+
+	employees := []*Employee{
+		makeEmployee(1, "2022-01-12T00:00:00+01:00", ""),
+		makeEmployee(2, "2022-05-05T00:00:00+02:00", "2024-06-01T00:00:00+02:00"),
+		makeEmployee(3, "2024-06-01T00:00:00+02:00", ""),
+		makeEmployee(4, "", ""),
+	}
+
+	headcountCases := []headcountTestCase{
+		{date: makeTime("2021-12-31T00:00:00Z"), wantCount: 0},
+		{date: makeTime("2022-05-05T00:00:00+02:00"), wantCount: 2},
+		{date: makeTime("2024-05-31T23:59:59+02:00"), wantCount: 2},
+		{date: makeTime("2024-06-01T00:00:00+02:00"), wantCount: 2},
+		{date: makeTime("2025-01-01T00:00:00Z"), wantCount: 2},
+	}
+
+	for testNumber, testCase := range headcountCases {
+		count := HeadcountOn(employees, testCase.date)
+		if count != testCase.wantCount {
+			t.Errorf("[%d] Expected headcount %d on %s, got %d", testNumber, testCase.wantCount, testCase.date, count)
+		}
+	}
+}
+
+func TestPartitionEmployeesByActive(t *testing.T) {
+
+	employees := []*Employee{
+		makeEmployee(1, "2022-01-12T00:00:00+01:00", ""),
+		makeEmployee(2, "2022-05-05T00:00:00+02:00", "2024-06-01T00:00:00+02:00"),
+		makeEmployee(3, "2024-06-01T00:00:00+02:00", ""),
+		makeEmployee(4, "", ""),
+		makeEmployee(5, "", "2022-03-01T00:00:00+01:00"),
+	}
+
+	testCases := []struct {
+		asOf           time.Time
+		wantActive     []int64
+		wantTerminated []int64
+		wantOther      []int64
+	}{
+		{asOf: makeTime("2021-12-31T00:00:00Z"), wantActive: []int64{}, wantTerminated: []int64{}, wantOther: []int64{1, 2, 3, 4, 5}},
+		{asOf: makeTime("2022-05-05T00:00:00+02:00"), wantActive: []int64{1, 2}, wantTerminated: []int64{5}, wantOther: []int64{3, 4}},
+		{asOf: makeTime("2024-06-01T00:00:00+02:00"), wantActive: []int64{1, 3}, wantTerminated: []int64{2, 5}, wantOther: []int64{4}},
+	}
+
+	ids := func(employees []*Employee) []int64 {
+		ids := make([]int64, len(employees))
+		for i, employee := range employees {
+			ids[i] = *employee.GetIntAttribute("id")
+		}
+		return ids
+	}
+
+	for testNumber, testCase := range testCases {
+		active, terminated, other := PartitionEmployeesByActive(employees, testCase.asOf)
+		if !reflect.DeepEqual(ids(active), testCase.wantActive) {
+			t.Errorf("[%d] Expected active %v, got %v", testNumber, testCase.wantActive, ids(active))
+		}
+		if !reflect.DeepEqual(ids(terminated), testCase.wantTerminated) {
+			t.Errorf("[%d] Expected terminated %v, got %v", testNumber, testCase.wantTerminated, ids(terminated))
+		}
+		if !reflect.DeepEqual(ids(other), testCase.wantOther) {
+			t.Errorf("[%d] Expected other %v, got %v", testNumber, testCase.wantOther, ids(other))
+		}
+		if len(active) != HeadcountOn(employees, testCase.asOf) {
+			t.Errorf("[%d] Expected active employees to match the headcount", testNumber)
+		}
+	}
+}
+
+func TestFilterEmployees(t *testing.T) {
+
+	employees := []*Employee{
+		makeEmployee(1, "2022-01-12T00:00:00+01:00", ""),
+		makeEmployee(2, "2022-05-05T00:00:00+02:00", "2024-06-01T00:00:00+02:00"),
+		makeEmployee(3, "2024-06-01T00:00:00+02:00", ""),
+	}
+
+	hiredIn2022 := func(e *Employee) bool {
+		hireDate := e.GetTimeAttribute("hire_date")
+		return hireDate != nil && hireDate.Year() == 2022
+	}
+	none := func(*Employee) bool { return false }
+
+	testCases := []struct {
+		employees []*Employee
+		pred      func(*Employee) bool
+		wantIds   []int64
+	}{
+		{employees: employees, pred: hiredIn2022, wantIds: []int64{1, 2}},
+		{employees: employees, pred: none, wantIds: []int64{}},
+		{employees: nil, pred: hiredIn2022, wantIds: []int64{}},
+	}
+
+	for testNumber, testCase := range testCases {
+		matched := FilterEmployees(testCase.employees, testCase.pred)
+		if matched == nil {
+			t.Errorf("[%d] Expected non-nil result", testNumber)
+			continue
+		}
+		ids := make([]int64, len(matched))
+		for i, employee := range matched {
+			ids[i] = *employee.GetIntAttribute("id")
+		}
+		if !reflect.DeepEqual(ids, testCase.wantIds) {
+			t.Errorf("[%d] Expected %v, got %v", testNumber, testCase.wantIds, ids)
+		}
+	}
+}
+
+func TestEmployee_ProfileURL(t *testing.T) {
+
+	testCases := []struct {
+		employee   *Employee
+		baseWebURL string
+		want       string
+	}{
+		{employee: makeEmployee(6205887, "", ""), baseWebURL: "https://example.personio.de", want: "https://example.personio.de/staff/details/6205887"},
+		{employee: makeEmployee(6205887, "", ""), baseWebURL: "https://example.personio.de/", want: "https://example.personio.de/staff/details/6205887"},
+		{employee: &Employee{}, baseWebURL: "https://example.personio.de", want: ""},
+	}
+
+	for testNumber, testCase := range testCases {
+		got := testCase.employee.ProfileURL(testCase.baseWebURL)
+		if got != testCase.want {
+			t.Errorf("[%d] Expected %q, got %q", testNumber, testCase.want, got)
+		}
+	}
+}
+
+type terminationDateTestCase struct {
+	attributes map[string]Attribute
+	wantDate   *time.Time
+}
+
+func TestEmployee_TerminationDate(t *testing.T) {
+
+	terminated := makeTime("2024-06-30T00:00:00Z")
+	terminatedOffset := makeTime("2024-06-30T00:00:00+02:00")
+	contractEnd := makeTime("2024-12-31T00:00:00Z")
+	terminationCases := []terminationDateTestCase{
+		{attributes: map[string]Attribute{"termination_date": {Value: nil, Type: "date"}, "contract_end_date": {Value: nil, Type: "date"}}, wantDate: nil},
+		{attributes: map[string]Attribute{"termination_date": {Value: "2024-06-30", Type: "date"}}, wantDate: &terminated},
+		{attributes: map[string]Attribute{"termination_date": {Value: "2024-06-30T00:00:00+02:00", Type: "date"}}, wantDate: &terminatedOffset},
+		{attributes: map[string]Attribute{"termination_date": {Value: nil, Type: "date"}, "contract_end_date": {Value: "2024-12-31", Type: "date"}}, wantDate: &contractEnd},
+		{attributes: map[string]Attribute{"termination_date": {Value: "2024-06-30", Type: "date"}, "contract_end_date": {Value: "2024-12-31", Type: "date"}}, wantDate: &terminated},
+		{attributes: map[string]Attribute{"termination_date": {Value: "30.06.2024", Type: "date"}}, wantDate: nil},
+	}
+
+	for testNumber, testCase := range terminationCases {
+		employee := Employee{Type: "Employee", AttributeContainer: AttributeContainer{Attributes: testCase.attributes}}
+		date := employee.TerminationDate()
+		if (date == nil) != (testCase.wantDate == nil) || (date != nil && !date.Equal(*testCase.wantDate)) {
+			t.Errorf("[%d] Expected termination date %v, got %v", testNumber, testCase.wantDate, date)
+		}
+	}
+}
+
+func TestClient_GetEmployeesWithManagers(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	employees, err := personio.GetEmployeesWithManagers()
+	if err != nil {
+		t.Errorf("Failed to query employees with managers: %s", err)
+		return
+	}
+
+	wantManagers := map[int64]int64{6205887: 7161253, 7161253: 0}
+	if len(employees) != len(wantManagers) {
+		t.Errorf("Expected %d employees, got %d", len(wantManagers), len(employees))
+		return
+	}
+
+	for _, employee := range employees {
+		id := *employee.GetIntAttribute("id")
+		wantManagerId := wantManagers[id]
+		if wantManagerId == 0 {
+			if employee.Manager != nil {
+				t.Errorf("Expected employee %d to have no manager, got %v", id, employee.Manager)
+			}
+			continue
+		}
+
+		if employee.Manager == nil || *employee.Manager.GetIntAttribute("id") != wantManagerId {
+			t.Errorf("Expected employee %d to have manager %d, got %v", id, wantManagerId, employee.Manager)
+		}
+	}
+
+	// supervisor outside of the fetched employees
+	orphan := makeEmployee(1, "", "")
+	orphan.Attributes["supervisor"] = Attribute{Type: "standard", Value: map[string]interface{}{
+		"type":       "Employee",
+		"attributes": map[string]interface{}{"id": map[string]interface{}{"label": "ID", "value": float64(2), "type": "integer"}},
+	}}
+	resolved := resolveManagers([]*Employee{orphan})
+	if len(resolved) != 1 || resolved[0].Manager != nil {
+		t.Errorf("Expected unresolvable manager to be nil, got %v", resolved)
+	}
+}
+
+func TestClient_SyncEmployee(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	desired := map[string]interface{}{
+		"first_name":     "El",
+		"id":             6205887,
+		"position":       "Chief Piper",
+		"dynamic_700551": "on call",
+	}
+
+	employee, err := personio.SyncEmployee(6205887, desired)
+	if err != nil {
+		t.Errorf("Failed to sync employee: %s", err)
+		return
+	}
+
+	patches := server.mock.employeePatches[6205887]
+	if len(patches) != 1 {
+		t.Errorf("Expected exactly one patch, got %v", patches)
+		return
+	}
+	wantPatch := map[string]interface{}{"position": "Chief Piper", "custom_attributes": map[string]interface{}{"dynamic_700551": "on call"}}
+	if !reflect.DeepEqual(patches[0], wantPatch) {
+		t.Errorf("Expected patch %v, got %v", wantPatch, patches[0])
+	}
+
+	position := employee.GetStringAttribute("position")
+	if position == nil || *position != "Chief Piper" {
+		t.Errorf("Expected refreshed employee with updated position, got %v", position)
+	}
+
+	// nothing left to change
+	_, err = personio.SyncEmployee(6205887, desired)
+	if err != nil {
+		t.Errorf("Failed to sync employee: %s", err)
+	}
+	if len(server.mock.employeePatches[6205887]) != 1 {
+		t.Errorf("Expected no additional patch for unchanged employee, got %v", server.mock.employeePatches[6205887])
+	}
+}
+
+type employeesByOfficeTestCase struct {
+	officeId int64
+	wantIds  []int64
+}
+
+func TestClient_GetEmployeesByOffice(t *testing.T) {
+
+	employeeCases := []employeesByOfficeTestCase{
+		{officeId: 201439, wantIds: []int64{6205887, 7161253}},
+		{officeId: 201440, wantIds: []int64{8274190}},
+		{officeId: 1, wantIds: []int64{}},
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	// Nova works in the Berlin office, everybody else remotely
+	server.mock.extraEmployees = []int64{8274190}
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	for testNumber, testCase := range employeeCases {
+		employees, err := personio.GetEmployeesByOffice(testCase.officeId)
+		if err != nil {
+			t.Errorf("[%d] Failed to query employees: %s", testNumber, err)
+			continue
+		}
+
+		if employees == nil || len(testCase.wantIds) != len(employees) {
+			t.Errorf("[%d] Expected %d employees, got %v", testNumber, len(testCase.wantIds), employees)
+			continue
+		}
+
+		for i, id := range testCase.wantIds {
+			if *employees[i].GetIntAttribute("id") != id {
+				t.Errorf("[%d] Expected employee with ID %d, got %d", testNumber, id, *employees[i].GetIntAttribute("id"))
+			}
+		}
+	}
+}
+
+type emailTestCase struct {
+	value     interface{}
+	wantEmail string
+	wantErr   bool
+}
+
+func TestEmployee_Email(t *testing.T) {
+
+	emailCases := []emailTestCase{
+		{value: "gonzo@giantswarm.io", wantEmail: "gonzo@giantswarm.io"},
+		{value: " mega@giantswarm.io ", wantEmail: "mega@giantswarm.io"},
+		{value: nil, wantErr: true},
+		{value: "", wantErr: true},
+		{value: "gonzo", wantErr: true},
+		{value: "gonzo@", wantErr: true},
+		{value: "gonzo@giantswarm.io, mega@giantswarm.io", wantErr: true},
+		{value: "El Gonzo <gonzo@giantswarm.io>", wantErr: true},
+	}
+
+	for testNumber, testCase := range emailCases {
+		employee := Employee{Type: "Employee", AttributeContainer: AttributeContainer{Attributes: map[string]Attribute{
+			"email": {Label: "Email", Value: testCase.value, Type: "standard", UniversalId: "email"},
+		}}}
+
+		email, err := employee.Email()
+		if (err != nil) != testCase.wantErr {
+			t.Errorf("[%d] Expected error %t, got %v", testNumber, testCase.wantErr, err)
+			continue
+		}
+		if email != testCase.wantEmail {
+			t.Errorf("[%d] Expected email \"%s\", got \"%s\"", testNumber, testCase.wantEmail, email)
+		}
+	}
+}
+
+func TestEmployee_MarshalStable(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	employee, err := personio.GetEmployee(6205887)
+	if err != nil {
+		t.Errorf("Failed to query employee: %s", err)
+		return
+	}
+
+	stable, err := employee.MarshalStable()
+	if err != nil {
+		t.Errorf("Failed to marshal employee: %s", err)
+		return
+	}
+
+	for _, want := range []string{`"id":6205887`, `"fix_salary":7042.42`, `"hire_date":"2022-01-12T00:00:00+01:00"`, `"dynamic_700552":["Go","Kubernetes"]`} {
+		if !strings.Contains(string(stable), want) {
+			t.Errorf("Expected %s in stable JSON, got %s", want, stable)
+		}
+	}
+
+	var restored Employee
+	err = restored.UnmarshalStable(stable)
+	if err != nil {
+		t.Errorf("Failed to unmarshal employee: %s", err)
+		return
+	}
+
+	restable, err := restored.MarshalStable()
+	if err != nil {
+		t.Errorf("Failed to marshal restored employee: %s", err)
+		return
+	}
+	if string(restable) != string(stable) {
+		t.Errorf("Expected round-trip to be stable\n%s\ngot\n%s", stable, restable)
+	}
+
+	for key := range employee.Attributes {
+		if !reflect.DeepEqual(employee.GetIntAttribute(key), restored.GetIntAttribute(key)) ||
+			!reflect.DeepEqual(employee.GetStringAttribute(key), restored.GetStringAttribute(key)) ||
+			!reflect.DeepEqual(employee.GetTagAttribute(key), restored.GetTagAttribute(key)) ||
+			!reflect.DeepEqual(employee.GetMapAttribute(key), restored.GetMapAttribute(key)) {
+			t.Errorf("Expected attribute %s to survive round-trip, got %v", key, restored.Attributes[key])
+		}
+		original, roundTripped := employee.GetTimeAttribute(key), restored.GetTimeAttribute(key)
+		if (original == nil) != (roundTripped == nil) || (original != nil && !original.Equal(*roundTripped)) {
+			t.Errorf("Expected date %s to survive round-trip, got %v", key, roundTripped)
+		}
+	}
+
+	if id := restored.SupervisorID(); id == nil || *id != 7161253 {
+		t.Errorf("Expected supervisor 7161253 after round-trip, got %v", id)
+	}
+}
+
+func TestEmployee_DisplayName(t *testing.T) {
+
+	withAttributes := func(values map[string]string) *Employee {
+		employee := makeEmployee(1, "", "")
+		for key, value := range values {
+			employee.Attributes[key] = Attribute{Value: value, Type: "standard", UniversalId: key}
+		}
+		return employee
+	}
+
+	testCases := []struct {
+		employee *Employee
+		want     string
+	}{
+		{employee: withAttributes(map[string]string{"preferred_name": "Gonzo", "first_name": "El", "last_name": "Gonzo", "email": "gonzo@giantswarm.io"}), want: "Gonzo"},
+		{employee: withAttributes(map[string]string{"preferred_name": " ", "first_name": "El", "last_name": "Gonzo", "email": "gonzo@giantswarm.io"}), want: "El Gonzo"},
+		{employee: withAttributes(map[string]string{"first_name": "Mega", "email": "mega@giantswarm.io"}), want: "Mega"},
+		{employee: withAttributes(map[string]string{"first_name": "", "email": "mega@giantswarm.io"}), want: "mega@giantswarm.io"},
+		{employee: withAttributes(nil), want: ""},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		if got := testCase.employee.DisplayName(); got != testCase.want {
+			t.Errorf("[%d] Expected display name %q, got %q", testCaseNumber, testCase.want, got)
+		}
+	}
+}
+
+func TestClient_GetEmployeesByTeam(t *testing.T) {
+
+	testCases := []struct {
+		teamId   int64
+		wantIds  []int64
+		wantName string
+	}{
+		{teamId: 935423, wantIds: []int64{6205887, 7161253}, wantName: "Cozy Plumbers"},
+		{teamId: 935424, wantIds: []int64{8274190}, wantName: "Night Owls"},
+		{teamId: 646241, wantIds: []int64{}},
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	// Nova is the only Night Owl
+	server.mock.extraEmployees = []int64{8274190}
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	for testNumber, testCase := range testCases {
+		employees, err := personio.GetEmployeesByTeam(testCase.teamId)
+		if err != nil {
+			t.Errorf("[%d] Failed to query employees: %s", testNumber, err)
+			continue
+		}
+
+		if employees == nil || len(testCase.wantIds) != len(employees) {
+			t.Errorf("[%d] Expected %d employees, got %v", testNumber, len(testCase.wantIds), employees)
+			continue
+		}
+
+		for i, id := range testCase.wantIds {
+			if *employees[i].GetIntAttribute("id") != id {
+				t.Errorf("[%d] Expected employee with ID %d, got %d", testNumber, id, *employees[i].GetIntAttribute("id"))
+			}
+			if team := employees[i].Team(); team == nil || team.Name != testCase.wantName {
+				t.Errorf("[%d] Expected team %s, got %v", testNumber, testCase.wantName, team)
+			}
+		}
+	}
+
+	if team := makeEmployee(1, "", "").Team(); team != nil {
+		t.Errorf("Expected no team, got %v", team)
+	}
+}
+
+func TestClient_WithDefaultActiveOnly(t *testing.T) {
+
+	withStatus := func(id int64, status string) *Employee {
+		employee := makeEmployee(id, "", "")
+		employee.Attributes["status"] = Attribute{Label: "Status", Value: status, Type: "standard", UniversalId: "status"}
+		return employee
+	}
+
+	active := activeEmployees([]*Employee{withStatus(1, "active"), withStatus(2, "inactive"), withStatus(3, "leave"), makeEmployee(4, "", ""), withStatus(5, "active")})
+	if len(active) != 2 || *active[0].GetIntAttribute("id") != 1 || *active[1].GetIntAttribute("id") != 5 {
+		t.Errorf("Expected active employees 1 and 5, got %v", active)
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithDefaultActiveOnly(true))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	// all fixture employees are active
+	employees, err := personio.GetEmployees()
+	if err != nil || len(employees) != 2 {
+		t.Errorf("Expected 2 active employees, got %v (%v)", employees, err)
+	}
+
+	employees, err = personio.GetEmployeesWithParams(url.Values{"status": {"inactive"}})
+	if err != nil || len(employees) != 2 {
+		t.Errorf("Expected unfiltered employees with status parameter, got %v (%v)", employees, err)
+	}
+
+	// syncs report employees becoming inactive like Nova
+	server.mock.extraEmployees = []int64{8274190}
+	employees, err = personio.GetEmployees()
+	if err != nil || len(employees) != 2 {
+		t.Errorf("Expected 2 active employees, got %v (%v)", employees, err)
+	}
+	employees, cursor, err := personio.GetEmployeesSince(SyncCursor{UpdatedAt: makeTime("2022-11-29T10:26:54Z"), Ids: []int64{7161253}})
+	if err != nil || len(employees) != 1 || *employees[0].GetIntAttribute("id") != 8274190 {
+		t.Errorf("Expected inactive employee 8274190 to be synced, got %v (%v)", employees, err)
+	}
+	if !cursor.UpdatedAt.Equal(makeTime("2022-11-29T12:03:15+01:00")) {
+		t.Errorf("Expected cursor at Nova's last modification, got %v", cursor)
+	}
+}
+
+func TestClient_HasProfilePicture(t *testing.T) {
+
+	testCases := []struct {
+		id   int64
+		want bool
+	}{
+		{id: 6205887, want: true},
+		{id: 7161253, want: false},
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		got, err := personio.HasProfilePicture(testCase.id)
+		if err != nil {
+			t.Errorf("[%d] Failed to check profile picture: %s", testCaseNumber, err)
+			continue
+		}
+		if got != testCase.want {
+			t.Errorf("[%d] Expected %t, got %t", testCaseNumber, testCase.want, got)
+		}
+	}
+
+	// errors other than 404 are reported
+	server.mock.rejectTokens = true
+	_, err = personio.HasProfilePicture(6205887)
+	if err == nil {
+		t.Errorf("Expected error for rejected token, got none")
+	}
+}
+
+func TestEmployee_LabeledValues(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	employee, err := personio.GetEmployee(6205887)
+	if err != nil {
+		t.Errorf("Failed to query employee: %s", err)
+		return
+	}
+
+	values := employee.LabeledValues()
+	if len(values) != len(employee.Attributes) {
+		t.Fatalf("Expected %d labeled values, got %d", len(employee.Attributes), len(values))
+	}
+
+	wantFirst := []LabeledValue{
+		{Key: "id", Label: "ID", Value: 6205887.0},
+		{Key: "first_name", Label: "First name", Value: "El"},
+	}
+	for i, want := range wantFirst {
+		if values[i] != want {
+			t.Errorf("[%d] Expected %v, got %v", i, want, values[i])
+		}
+	}
+	if last := values[len(values)-1]; last.Key != "dynamic_700553" || last.Label != "Addresses" {
+		t.Errorf("Expected addresses last, got %v", last)
+	}
+
+	unlabeled := Employee{AttributeContainer: AttributeContainer{Attributes: map[string]Attribute{"nickname": {Value: "Gonzo"}}}}
+	if values := unlabeled.LabeledValues(); len(values) != 1 || values[0].Label != "nickname" {
+		t.Errorf("Expected key as label, got %v", values)
+	}
+}
+
+func TestEmployee_CostCenters(t *testing.T) {
+
+	testCases := []struct {
+		value interface{}
+		want  []string
+	}{
+		{value: "Engineering", want: []string{"Engineering"}},
+		{value: " ", want: []string{}},
+		{value: []interface{}{"Engineering", "Sales"}, want: []string{"Engineering", "Sales"}},
+		{value: []interface{}{map[string]interface{}{"type": "CostCenter", "attributes": map[string]interface{}{"id": 4711.0, "name": "Engineering"}}}, want: []string{"Engineering"}},
+		{value: map[string]interface{}{"type": "CostCenter", "attributes": map[string]interface{}{"name": "Sales"}}, want: []string{"Sales"}},
+		{value: []interface{}{}, want: []string{}},
+		{value: nil, want: []string{}},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		employee := Employee{AttributeContainer: AttributeContainer{Attributes: map[string]Attribute{"dynamic_4711": {Type: "standard", Value: testCase.value}}}}
+		got := employee.CostCentersFromAttribute("dynamic_4711")
+		if got == nil || strings.Join(got, "|") != strings.Join(testCase.want, "|") {
+			t.Errorf("[%d] Expected cost centers %v, got %v", testCaseNumber, testCase.want, got)
+		}
+		if got := employee.CostCenters(); got == nil || len(got) != 0 {
+			t.Errorf("[%d] Expected no cost centers for default key, got %v", testCaseNumber, got)
+		}
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	wantCostCenters := map[int64]string{6205887: "", 7161253: "", 8274190: "Engineering"}
+	for id, want := range wantCostCenters {
+		employee, err := personio.GetEmployee(id)
+		if err != nil {
+			t.Errorf("[%d] Failed to query employee: %s", id, err)
+			continue
+		}
+		if got := strings.Join(employee.CostCenters(), "|"); got != want {
+			t.Errorf("[%d] Expected cost centers %q, got %q", id, want, got)
+		}
+	}
+}
+
+func TestClient_ResolveEmployeeRefAttribute(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		_ = server.Close()
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	gonzo, err := personio.GetEmployee(6205887)
+	if err != nil {
+		_ = server.Close()
+		t.Fatalf("Failed to query employee: %s", err)
+	}
+
+	testCases := []struct {
+		value  interface{}
+		wantId int64
+	}{
+		{value: 7161253.0, wantId: 7161253},
+		{value: "6205887", wantId: 6205887},
+		{value: map[string]interface{}{"id": 7161253.0}, wantId: 7161253},
+		{value: map[string]interface{}{"type": "Employee", "attributes": map[string]interface{}{"id": map[string]interface{}{"label": "ID", "value": 6205887.0}}}, wantId: 6205887},
+		{value: nil},
+		{value: "n/a"},
+		{value: map[string]interface{}{"name": "Mega"}},
+	}
+
+	for testNumber, testCase := range testCases {
+		employee := Employee{AttributeContainer: AttributeContainer{Attributes: map[string]Attribute{"mentor": {Label: "Mentor", Value: testCase.value}}}}
+		mentor, err := personio.ResolveEmployeeRefAttribute(&employee, "mentor")
+		if err != nil {
+			t.Errorf("[%d] Failed to resolve mentor: %s", testNumber, err)
+			continue
+		}
+		if testCase.wantId == 0 {
+			if mentor != nil {
+				t.Errorf("[%d] Expected no mentor, got %v", testNumber, mentor)
+			}
+			continue
+		}
+		if mentor == nil {
+			t.Errorf("[%d] Expected mentor %d, got none", testNumber, testCase.wantId)
+		} else if id := mentor.GetIntAttribute("id"); id == nil || *id != testCase.wantId {
+			t.Errorf("[%d] Expected mentor %d, got %v", testNumber, testCase.wantId, id)
+		}
+	}
+
+	if missing, err := personio.ResolveEmployeeRefAttribute(gonzo, "mentor"); missing != nil || err != nil {
+		t.Errorf("Expected nil for missing attribute, got %v and %v", missing, err)
+	}
+
+	// Mega's supervisor is unset, El Gonzo's supervisor Mega
+	chain, err := personio.ResolveEmployeeRefChain(gonzo, "supervisor", 5)
+	if err != nil || len(chain) != 1 || *chain[0].GetIntAttribute("id") != 7161253 {
+		t.Errorf("Expected supervisor chain of Mega, got %v and %v", chain, err)
+	}
+
+	// referenced employees are served from cache once the server is gone
+	_ = server.Close()
+
+	circular := Employee{AttributeContainer: AttributeContainer{Attributes: map[string]Attribute{
+		"id":         {Type: "integer", Value: 7161253.0},
+		"supervisor": {Value: 6205887.0},
+	}}}
+	chain, err = personio.ResolveEmployeeRefChain(&circular, "supervisor", 5)
+	if err != nil || len(chain) != 1 || *chain[0].GetIntAttribute("id") != 6205887 {
+		t.Errorf("Expected cycle back to Mega to end the chain after El Gonzo, got %v and %v", chain, err)
+	}
+	if chain, err := personio.ResolveEmployeeRefChain(&circular, "supervisor", 0); err != nil || len(chain) != 0 {
+		t.Errorf("Expected empty chain at depth 0, got %v and %v", chain, err)
+	}
+}
+
+func TestClient_GetEmployeesSince(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	// El Gonzo was last modified at 2022-11-29T10:11:52+01:00, Mega at 2022-11-29T11:26:54+01:00
+	testCases := []struct {
+		cursor     SyncCursor
+		wantIds    []int64
+		wantCursor SyncCursor
+	}{
+		{cursor: SyncCursor{}, wantIds: []int64{6205887, 7161253}, wantCursor: SyncCursor{UpdatedAt: makeTime("2022-11-29T10:26:54Z"), Ids: []int64{7161253}}},
+		{cursor: SyncCursor{UpdatedAt: makeTime("2022-11-29T09:11:52Z")}, wantIds: []int64{6205887, 7161253}, wantCursor: SyncCursor{UpdatedAt: makeTime("2022-11-29T10:26:54Z"), Ids: []int64{7161253}}},
+		{cursor: SyncCursor{UpdatedAt: makeTime("2022-11-29T09:11:52Z"), Ids: []int64{6205887}}, wantIds: []int64{7161253}, wantCursor: SyncCursor{UpdatedAt: makeTime("2022-11-29T10:26:54Z"), Ids: []int64{7161253}}},
+		{cursor: SyncCursor{UpdatedAt: makeTime("2022-11-29T10:26:54Z"), Ids: []int64{7161253}}, wantIds: []int64{}, wantCursor: SyncCursor{UpdatedAt: makeTime("2022-11-29T10:26:54Z"), Ids: []int64{7161253}}},
+		{cursor: SyncCursor{UpdatedAt: makeTime("2023-01-01T00:00:00Z")}, wantIds: []int64{}, wantCursor: SyncCursor{UpdatedAt: makeTime("2023-01-01T00:00:00Z")}},
+	}
+
+	// cursors keep the precise timestamps even if dates are normalized
+	for _, opts := range [][]Option{nil, {WithDateOnlyNormalization()}} {
+		personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+		personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, opts...)
+		if err != nil {
+			t.Errorf("Failed to create Personio API v1 client: %s", err)
+			return
+		}
+		server.mock.lastToken = ""
+
+		for testCaseNumber, testCase := range testCases {
+			employees, cursor, err := personio.GetEmployeesSince(testCase.cursor)
+			if err != nil {
+				t.Errorf("[%d/%d] Failed to query employees: %s", len(opts), testCaseNumber, err)
+				continue
+			}
+
+			ids := make([]int64, 0, len(employees))
+			for _, employee := range employees {
+				ids = append(ids, *employee.GetIntAttribute("id"))
+			}
+			if !reflect.DeepEqual(ids, testCase.wantIds) {
+				t.Errorf("[%d/%d] Expected employees %v, got %v", len(opts), testCaseNumber, testCase.wantIds, ids)
+			}
+			if !cursor.UpdatedAt.Equal(testCase.wantCursor.UpdatedAt) || !reflect.DeepEqual(cursor.Ids, testCase.wantCursor.Ids) {
+				t.Errorf("[%d/%d] Expected cursor %v, got %v", len(opts), testCaseNumber, testCase.wantCursor, cursor)
+			}
+		}
+	}
+}
+
+func TestEmployee_SupervisorID(t *testing.T) {
+
+	testCases := []struct {
+		fixture string
+		wantId  int64
+	}{
+		// supervisor as nested employee object
+		{fixture: "employee-6205887.json", wantId: 7161253},
+		// supervisor as bare ID
+		{fixture: "employee-supervisor-id.json", wantId: 7161253},
+		// no supervisor
+		{fixture: "employee-7161253.json", wantId: 0},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		data, err := os.ReadFile(filepath.Join("testdata", testCase.fixture))
+		if err != nil {
+			t.Fatalf("[%d] Failed to read fixture: %s", testCaseNumber, err)
+		}
+		var result employeeResult
+		err = json.Unmarshal(data, &result)
+		if err != nil {
+			t.Fatalf("[%d] Failed to decode fixture: %s", testCaseNumber, err)
+		}
+
+		id := result.Data.SupervisorID()
+		if testCase.wantId == 0 {
+			if id != nil {
+				t.Errorf("[%d] Expected no supervisor, got %d", testCaseNumber, *id)
+			}
+		} else if id == nil || *id != testCase.wantId {
+			t.Errorf("[%d] Expected supervisor %d, got %v", testCaseNumber, testCase.wantId, id)
+		}
+	}
+
+	var employee *Employee
+	if id := employee.SupervisorID(); id != nil {
+		t.Errorf("Expected no supervisor of nil employee, got %d", *id)
+	}
+}
@@ -0,0 +1,198 @@
+package v1
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	util "github.com/giantswarm/personio-go"
+)
+
+// DefaultTimeOffCSVColumns are the columns ExportTimeOffsCSV writes if none are specified
+var DefaultTimeOffCSVColumns = []string{"id", "employee_id", "employee_email", "time_off_type", "status", "start_date", "end_date", "half_day_start", "half_day_end", "days_count"}
+
+// exportPageSize is the number of time-offs or employees fetched per page while exporting
+var exportPageSize = pagingMaxLimit
+
+// formatCSVFloat formats a number without superfluous digits
+func formatCSVFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// timeOffCSVColumns are the values available as columns of ExportTimeOffsCSV
+var timeOffCSVColumns = map[string]func(t *TimeOff) string{
+	"id":             func(t *TimeOff) string { return strconv.FormatInt(t.Id, 10) },
+	"status":         func(t *TimeOff) string { return t.Status },
+	"comment":        func(t *TimeOff) string { return t.Comment },
+	"start_date":     func(t *TimeOff) string { return util.FormatPersonioDate(t.StartDate, nil) },
+	"end_date":       func(t *TimeOff) string { return util.FormatPersonioDate(t.EndDate, nil) },
+	"half_day_start": func(t *TimeOff) string { return strconv.FormatBool(bool(t.HalfDayStart)) },
+	"half_day_end":   func(t *TimeOff) string { return strconv.FormatBool(bool(t.HalfDayEnd)) },
+	"days_count":     func(t *TimeOff) string { return formatCSVFloat(t.DaysCount) },
+	"measurement_unit": func(t *TimeOff) string {
+		_, unit := t.Amount()
+		return unit
+	},
+	"effective_duration": func(t *TimeOff) string {
+		value, _ := t.Amount()
+		return formatCSVFloat(value)
+	},
+	"time_off_type_id": func(t *TimeOff) string { return strconv.FormatInt(t.TimeOffType.Attributes.Id, 10) },
+	"time_off_type":    func(t *TimeOff) string { return t.TimeOffType.Attributes.Name },
+	"category":         func(t *TimeOff) string { return t.TimeOffType.Attributes.Category },
+	"employee_id": func(t *TimeOff) string {
+		if id := t.EmployeeID(); id != nil {
+			return strconv.FormatInt(*id, 10)
+		}
+		return ""
+	},
+	"employee_email": func(t *TimeOff) string {
+		if email := t.EmployeeEmail(); email != nil {
+			return *email
+		}
+		return ""
+	},
+	"employee_name": func(t *TimeOff) string { return t.Employee.DisplayName() },
+	"created_by":    func(t *TimeOff) string { return t.CreatedBy },
+	"created_at":    func(t *TimeOff) string { return t.CreatedAt.Format(time.RFC3339) },
+	"updated_at":    func(t *TimeOff) string { return t.UpdatedAt.Format(time.RFC3339) },
+}
+
+// ExportTimeOffsCSV writes the time-offs matching start and end (inclusive, ignored if nil) as CSV to w, page by page
+//
+// The header row lists the columns (DefaultTimeOffCSVColumns if empty), unknown columns fail before anything is written.
+// Rows are flushed after every page, so memory use is bounded regardless of the number of time-offs and WithMaxResults
+// does not apply. Cancelling ctx or exceeding the total timeout set via WithTotalTimeout aborts the export, rows written
+// so far remain written.
+func (personio *Client) ExportTimeOffsCSV(ctx context.Context, w io.Writer, start *time.Time, end *time.Time, columns []string) error {
+
+	ctx, cancel := personio.withTotalTimeout(ctx)
+	defer cancel()
+
+	if len(columns) == 0 {
+		columns = DefaultTimeOffCSVColumns
+	}
+	values := make([]func(t *TimeOff) string, len(columns))
+	for i, column := range columns {
+		value, ok := timeOffCSVColumns[column]
+		if !ok {
+			return fmt.Errorf("unknown time-off CSV column %q", column)
+		}
+		values[i] = value
+	}
+
+	writer := csv.NewWriter(w)
+	err := writer.Write(columns)
+	if err != nil {
+		return err
+	}
+
+	query := timeOffsQuery(start, end)
+	row := make([]string, len(columns))
+	for page := 0; ; page++ {
+
+		err = ctx.Err()
+		if err != nil {
+			return err
+		}
+
+		// time-offs endpoint offset's unit is pages
+		result, err := personio.getPage(ctx, "/company/time-offs", query, page, exportPageSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range result.Data {
+			var timeOff timeOffContainer
+			err = json.Unmarshal(result.Data[i], &timeOff)
+			if err != nil {
+				return err
+			}
+			personio.applyAttributeConfig(&timeOff.Attributes.Employee)
+			for j, value := range values {
+				row[j] = value(&timeOff.Attributes)
+			}
+			err = writer.Write(row)
+			if err != nil {
+				return err
+			}
+		}
+
+		writer.Flush()
+		err = writer.Error()
+		if err != nil {
+			return err
+		}
+
+		if len(result.Data) < exportPageSize {
+			return nil
+		}
+	}
+}
+
+// WriteEmployeesNDJSON writes the employees as newline-delimited JSON, one object per line as serialized by MarshalStable
+func WriteEmployeesNDJSON(w io.Writer, employees []*Employee) error {
+	for _, employee := range employees {
+		data, err := employee.MarshalStable()
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportEmployeesNDJSON writes all employees (only active ones if WithDefaultActiveOnly is set) to w like
+// WriteEmployeesNDJSON, fetching and writing them page by page instead of holding all employees in memory
+//
+// Requests are bound to ctx and share the total timeout set via WithTotalTimeout, the export stops with ctx.Err() once
+// it's done. Employees written before an error remain written.
+func (personio *Client) ExportEmployeesNDJSON(ctx context.Context, w io.Writer) error {
+
+	ctx, cancel := personio.withTotalTimeout(ctx)
+	defer cancel()
+
+	query := url.Values{}
+	for offset := 0; ; {
+
+		err := ctx.Err()
+		if err != nil {
+			return err
+		}
+
+		result, err := personio.getPage(ctx, "/company/employees", query, offset, exportPageSize)
+		if err != nil {
+			return err
+		}
+
+		employees := make([]*Employee, len(result.Data))
+		for i := range result.Data {
+			employees[i] = &Employee{}
+			err = json.Unmarshal(result.Data[i], employees[i])
+			if err != nil {
+				return err
+			}
+			personio.applyAttributeConfig(employees[i])
+		}
+		offset += len(employees)
+
+		err = WriteEmployeesNDJSON(w, personio.filterDefaultActive(employees, query))
+		if err != nil {
+			return err
+		}
+
+		// Personio may cap the page size below the requested one
+		announced := result.Metadata != nil && offset < result.Metadata.TotalElements
+		if len(result.Data) == 0 || (len(result.Data) < exportPageSize && !announced) {
+			return nil
+		}
+	}
+}
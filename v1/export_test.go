@@ -0,0 +1,205 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingWriter counts the writes to the underlying buffer
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+// Write records a single write
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestClient_ExportTimeOffsCSV(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	// one time-off per page
+	defer func(pageSize int) {
+		exportPageSize = pageSize
+	}(exportPageSize)
+	exportPageSize = 1
+
+	end := makeTime("2022-10-01T00:00:00Z")
+	testCases := []struct {
+		end     *time.Time
+		columns []string
+		want    string
+	}{
+		{end: nil, columns: nil, want: "id,employee_id,employee_email,time_off_type,status,start_date,end_date,half_day_start,half_day_end,days_count\n" +
+			"125814620,7161253,mega@giantswarm.io,Vacation,approved,2022-09-05,2022-09-09,false,false,5\n" +
+			"125682392,6205887,gonzo@giantswarm.io,Vacation,approved,2022-09-07,2022-09-14,false,false,6\n" +
+			"125682393,6205887,gonzo@giantswarm.io,Vacation,approved,2022-12-01,2022-12-01,false,true,0.5\n"},
+		{end: &end, columns: []string{"id", "employee_name", "category"}, want: "id,employee_name,category\n" +
+			"125814620,Mega Hui,paid_vacation\n" +
+			"125682392,El Gonzo,paid_vacation\n"},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		var w countingWriter
+		err = personio.ExportTimeOffsCSV(context.TODO(), &w, nil, testCase.end, testCase.columns)
+		if err != nil {
+			t.Errorf("[%d] Failed to export time-offs: %s", testCaseNumber, err)
+			continue
+		}
+		if w.String() != testCase.want {
+			t.Errorf("[%d] Expected CSV\n%s\ngot\n%s", testCaseNumber, testCase.want, w.String())
+		}
+		if rows := strings.Count(testCase.want, "\n") - 1; w.writes < rows {
+			t.Errorf("[%d] Expected rows to be written page by page, got %d writes for %d rows", testCaseNumber, w.writes, rows)
+		}
+	}
+
+	var w bytes.Buffer
+	err = personio.ExportTimeOffsCSV(context.TODO(), &w, nil, nil, []string{"id", "unknown"})
+	if err == nil || w.Len() != 0 {
+		t.Errorf("Expected unknown column to fail before writing, got %v and %q", err, w.String())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = personio.ExportTimeOffsCSV(ctx, &w, nil, nil, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestClient_ExportEmployeesNDJSON(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	employees, err := personio.GetEmployees()
+	if err != nil {
+		t.Fatalf("Failed to query employees: %s", err)
+	}
+	var want bytes.Buffer
+	err = WriteEmployeesNDJSON(&want, employees)
+	if err != nil {
+		t.Fatalf("Failed to write employees: %s", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(want.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		var employee Employee
+		err = employee.UnmarshalStable([]byte(line))
+		if err != nil {
+			t.Errorf("[%d] Failed to decode line: %s", i, err)
+		} else if id := employee.GetIntAttribute("id"); id == nil || *id != *employees[i].GetIntAttribute("id") {
+			t.Errorf("[%d] Expected employee %d, got %v", i, *employees[i].GetIntAttribute("id"), id)
+		}
+	}
+
+	// Personio returning one employee per page
+	server.mock.maxPageSize = 1
+
+	var w countingWriter
+	err = personio.ExportEmployeesNDJSON(context.TODO(), &w)
+	if err != nil {
+		t.Fatalf("Failed to export employees: %s", err)
+	}
+	if w.String() != want.String() {
+		t.Errorf("Expected NDJSON\n%s\ngot\n%s", want.String(), w.String())
+	}
+	if w.writes != 2 {
+		t.Errorf("Expected employees to be written page by page, got %d writes", w.writes)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = personio.ExportEmployeesNDJSON(ctx, &w)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestClient_ExportEmployeesNDJSONPaged(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	// three employees on three pages, inactive Nova is filtered like by GetEmployees
+	server.mock.maxPageSize = 1
+	server.mock.extraEmployees = []int64{8274190}
+
+	transport := &slowTransport{path: "/company/employees", delay: 50 * time.Millisecond}
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithHTTPClient(&http.Client{Transport: transport}), WithDefaultActiveOnly(true))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	var w countingWriter
+	err = personio.ExportEmployeesNDJSON(context.TODO(), &w)
+	if err != nil {
+		t.Fatalf("Failed to export employees: %s", err)
+	}
+	if lines := strings.Count(w.String(), "\n"); lines != 2 || strings.Contains(w.String(), "8274190") {
+		t.Errorf("Expected the 2 active employees, got\n%s", w.String())
+	}
+
+	// each page fits the budget but all of them don't
+	server.mock.lastToken = ""
+	personio, err = NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithHTTPClient(&http.Client{Transport: transport}), WithTotalTimeout(125*time.Millisecond))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+	err = personio.ExportEmployeesNDJSON(context.TODO(), &w)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
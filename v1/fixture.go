@@ -0,0 +1,151 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// fixtureEnvelope is the response envelope of Personio API v1 as decoded by ValidateFixture
+type fixtureEnvelope struct {
+	Success bool `json:"success"`
+	Error   struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	Metadata json.RawMessage `json:"metadata"`
+	Offset   int             `json:"offset"`
+	Limit    int             `json:"limit"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// typedObject is the type discriminator of objects returned by Personio
+type typedObject struct {
+	Type string `json:"type"`
+}
+
+// fixtureEmployee mirrors Employee without its custom unmarshalling, which does not decode strictly
+type fixtureEmployee struct {
+	Type       string               `json:"type"`
+	Attributes map[string]Attribute `json:"attributes"`
+}
+
+// nestedEmployee is the employee embedded in time-off and absence periods
+type nestedEmployee struct {
+	Attributes struct {
+		Employee json.RawMessage `json:"employee"`
+	} `json:"attributes"`
+}
+
+// newFixtureTarget returns the typed struct objects of the specified Personio type are decoded into
+func newFixtureTarget(objectType string) (interface{}, error) {
+	switch objectType {
+	case "Employee":
+		return &fixtureEmployee{}, nil
+	case "TimeOffPeriod":
+		return &timeOffContainer{}, nil
+	case "AbsencePeriod":
+		return &absenceContainer{}, nil
+	case "AttendancePeriod":
+		return &attendanceContainer{}, nil
+	case "TimeOffType":
+		return &timeOffTypeContainer{}, nil
+	case "Document":
+		return &documentContainer{}, nil
+	}
+	return nil, fmt.Errorf("unknown object type %q", objectType)
+}
+
+// decodeStrict decodes data into v, failing on unknown fields and trailing data
+func decodeStrict(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	if err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("unexpected data after JSON document")
+	}
+	return nil
+}
+
+// validateObject strictly decodes a single typed Personio object
+func validateObject(data []byte) error {
+	var object typedObject
+	err := json.Unmarshal(data, &object)
+	if err != nil {
+		return err
+	}
+
+	target, err := newFixtureTarget(object.Type)
+	if err != nil {
+		return err
+	}
+
+	err = decodeStrict(data, target)
+	if err != nil {
+		return err
+	}
+
+	// embedded employees are decoded by Employee's custom unmarshalling, so they are checked separately
+	if object.Type == "TimeOffPeriod" || object.Type == "AbsencePeriod" {
+		var nested nestedEmployee
+		err = json.Unmarshal(data, &nested)
+		if err != nil {
+			return err
+		}
+		employee := bytes.TrimSpace(nested.Attributes.Employee)
+		if len(employee) > 0 && !bytes.Equal(employee, []byte("null")) {
+			err = decodeStrict(employee, &fixtureEmployee{})
+			if err != nil {
+				return fmt.Errorf("employee: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateFixture checks that a recorded Personio response (or a single object of it) decodes into the typed structs
+//
+// Decoding is strict, so fields unknown to the structs are reported. This detects schema drift of recorded fixtures.
+func ValidateFixture(data []byte) error {
+
+	var probe map[string]json.RawMessage
+	err := json.Unmarshal(data, &probe)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := probe["data"]; !ok {
+		return validateObject(data)
+	}
+
+	var envelope fixtureEnvelope
+	err = decodeStrict(data, &envelope)
+	if err != nil {
+		return err
+	}
+
+	trimmed := bytes.TrimSpace(envelope.Data)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return validateObject(envelope.Data)
+	}
+
+	var elements []json.RawMessage
+	err = json.Unmarshal(envelope.Data, &elements)
+	if err != nil {
+		return err
+	}
+
+	for i := range elements {
+		err = validateObject(elements[i])
+		if err != nil {
+			return fmt.Errorf("data[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package v1
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateFixture(t *testing.T) {
+
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil || len(fixtures) == 0 {
+		t.Errorf("Failed to list fixtures: %v", err)
+		return
+	}
+
+	for _, fixture := range fixtures {
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Errorf("Failed to read %s: %s", fixture, err)
+			continue
+		}
+		err = ValidateFixture(data)
+		if err != nil {
+			t.Errorf("Expected fixture %s to be valid, got %s", fixture, err)
+		}
+	}
+
+	invalidCases := []string{
+		`{"success": true, "data": [{"type": "Employee", "attributes": {"id": {"label": "ID", "value": 1, "type": "integer", "renamed": "id"}}}]}`,
+		`{"success": true, "data": [{"type": "Unknown", "attributes": {}}]}`,
+		`{"success": true, "data": {"type": "TimeOffPeriod", "attributes": {"id": "125814620"}}}`,
+		`{"success": true, "data": {"type": "TimeOffPeriod", "attributes": {"id": 1, "employee": {"type": "Employee", "attributes": {"id": {"label": "ID", "value": 1, "type": "integer", "renamed": "id"}}}}}}`,
+		`{"success": true, "paging": {}, "data": []}`,
+		`not json`,
+	}
+
+	for testCaseNumber, testCase := range invalidCases {
+		if err := ValidateFixture([]byte(testCase)); err == nil {
+			t.Errorf("[%d] Expected fixture to be invalid", testCaseNumber)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package v1
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// icsDateFormat is the iCalendar DATE value format
+const icsDateFormat = "20060102"
+
+// icsTimestampFormat is the iCalendar UTC DATE-TIME value format
+const icsTimestampFormat = "20060102T150405Z"
+
+// icsLineLimit is the maximum length of an iCalendar content line in octets, excluding the line break
+const icsLineLimit = 75
+
+// icsEscaper escapes iCalendar TEXT values
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// icsWriter writes folded iCalendar content lines, keeping the first error
+type icsWriter struct {
+	w   io.Writer
+	err error
+}
+
+// line writes a single content line, folded to icsLineLimit octets
+func (ics *icsWriter) line(format string, v ...interface{}) {
+	if ics.err != nil {
+		return
+	}
+
+	content := fmt.Sprintf(format, v...)
+	var folded strings.Builder
+	limit := icsLineLimit
+	for len(content) > limit {
+		// do not split multi-byte UTF-8 sequences
+		cut := limit
+		for cut > 0 && content[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		folded.WriteString(content[:cut])
+		folded.WriteString("\r\n ")
+		content = content[cut:]
+		limit = icsLineLimit - 1
+	}
+	folded.WriteString(content)
+	folded.WriteString("\r\n")
+
+	_, ics.err = io.WriteString(ics.w, folded.String())
+}
+
+// WriteTimeOffsICS writes the time-offs as iCalendar (RFC 5545) with one all-day VEVENT per time-off
+//
+// The event summary is provided by titleFn, defaulting to the time-off type's name if nil.
+// Half days are annotated in the summary as Personio does not provide the times of day they cover.
+func WriteTimeOffsICS(w io.Writer, offs []*TimeOff, titleFn func(*TimeOff) string) error {
+
+	if titleFn == nil {
+		titleFn = func(timeOff *TimeOff) string {
+			return timeOff.TimeOffType.Attributes.Name
+		}
+	}
+
+	ics := &icsWriter{w: w}
+	ics.line("BEGIN:VCALENDAR")
+	ics.line("VERSION:2.0")
+	ics.line("PRODID:-//giantswarm//personio-go//EN")
+	ics.line("CALSCALE:GREGORIAN")
+
+	for _, timeOff := range offs {
+		if timeOff == nil {
+			continue
+		}
+
+		stamp := timeOff.UpdatedAt
+		if stamp.IsZero() {
+			stamp = timeOff.CreatedAt
+		}
+
+		summary := titleFn(timeOff)
+		halfDayStart, halfDayEnd := bool(timeOff.HalfDayStart), bool(timeOff.HalfDayEnd)
+		switch {
+		case halfDayStart && halfDayEnd:
+			summary += " (half day start and end)"
+		case halfDayStart:
+			summary += " (half day start)"
+		case halfDayEnd:
+			summary += " (half day end)"
+		}
+
+		// DTEND of all-day events is exclusive
+		startYear, startMonth, startDay := timeOff.StartDate.Date()
+		endYear, endMonth, endDay := timeOff.EndDate.Date()
+		start := time.Date(startYear, startMonth, startDay, 0, 0, 0, 0, time.UTC)
+		end := time.Date(endYear, endMonth, endDay, 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+		if !end.After(start) {
+			end = start.AddDate(0, 0, 1)
+		}
+
+		ics.line("BEGIN:VEVENT")
+		ics.line("UID:time-off-%d@personio", timeOff.Id)
+		ics.line("DTSTAMP:%s", stamp.UTC().Format(icsTimestampFormat))
+		ics.line("DTSTART;VALUE=DATE:%s", start.Format(icsDateFormat))
+		ics.line("DTEND;VALUE=DATE:%s", end.Format(icsDateFormat))
+		ics.line("SUMMARY:%s", icsEscaper.Replace(summary))
+		ics.line("TRANSP:OPAQUE")
+		ics.line("END:VEVENT")
+	}
+
+	ics.line("END:VCALENDAR")
+
+	return ics.err
+}
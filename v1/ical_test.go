@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingWriter fails all writes
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestWriteTimeOffsICS(t *testing.T) {
+
+	vacation := &TimeOff{
+		Id:        125814620,
+		StartDate: time.Date(2022, 9, 5, 0, 0, 0, 0, time.FixedZone("CEST", 2*3600)),
+		EndDate:   time.Date(2022, 9, 9, 0, 0, 0, 0, time.FixedZone("CEST", 2*3600)),
+		UpdatedAt: time.Date(2022, 8, 1, 12, 30, 0, 0, time.UTC),
+	}
+	vacation.TimeOffType.Attributes.Name = "Vacation"
+
+	appointment := &TimeOff{
+		Id:           125682392,
+		StartDate:    time.Date(2022, 9, 12, 0, 0, 0, 0, time.UTC),
+		EndDate:      time.Date(2022, 9, 12, 0, 0, 0, 0, time.UTC),
+		HalfDayStart: true,
+		CreatedAt:    time.Date(2022, 8, 2, 8, 0, 0, 0, time.UTC),
+	}
+	appointment.TimeOffType.Attributes.Name = "Medical appointment"
+
+	var out strings.Builder
+	err := WriteTimeOffsICS(&out, []*TimeOff{vacation, appointment}, nil)
+	if err != nil {
+		t.Errorf("Failed to write ICS: %s", err)
+		return
+	}
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//giantswarm//personio-go//EN",
+		"CALSCALE:GREGORIAN",
+		"BEGIN:VEVENT",
+		"UID:time-off-125814620@personio",
+		"DTSTAMP:20220801T123000Z",
+		"DTSTART;VALUE=DATE:20220905",
+		"DTEND;VALUE=DATE:20220910",
+		"SUMMARY:Vacation",
+		"TRANSP:OPAQUE",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:time-off-125682392@personio",
+		"DTSTAMP:20220802T080000Z",
+		"DTSTART;VALUE=DATE:20220912",
+		"DTEND;VALUE=DATE:20220913",
+		"SUMMARY:Medical appointment (half day start)",
+		"TRANSP:OPAQUE",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	if out.String() != want {
+		t.Errorf("Expected ICS\n%s\ngot\n%s", want, out.String())
+	}
+
+	// escaping and folding
+	out.Reset()
+	title := strings.Repeat("Vacation, Mega Hui; ", 5)
+	err = WriteTimeOffsICS(&out, []*TimeOff{vacation}, func(*TimeOff) string { return title })
+	if err != nil {
+		t.Errorf("Failed to write ICS: %s", err)
+		return
+	}
+	for _, line := range strings.Split(out.String(), "\r\n") {
+		if len(line) > icsLineLimit {
+			t.Errorf("Expected lines of at most %d octets, got %d: %s", icsLineLimit, len(line), line)
+		}
+	}
+	unfolded := strings.ReplaceAll(out.String(), "\r\n ", "")
+	if !strings.Contains(unfolded, "SUMMARY:"+strings.Repeat(`Vacation\, Mega Hui\; `, 5)+"\r\n") {
+		t.Errorf("Expected escaped summary, got %s", unfolded)
+	}
+
+	err = WriteTimeOffsICS(failingWriter{}, []*TimeOff{vacation}, nil)
+	if err == nil {
+		t.Errorf("Expected write error, got none")
+	}
+}
@@ -0,0 +1,296 @@
+package v1
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultAuthContentType is the Content-Type of authentication requests unless changed via WithAuthContentType
+const DefaultAuthContentType = "application/x-www-form-urlencoded"
+
+// DefaultAuthMethod and DefaultAuthPath are the HTTP method and path (relative to the base URL) of authentication
+// requests unless changed via WithAuthEndpoint
+const (
+	DefaultAuthMethod = http.MethodPost
+	DefaultAuthPath   = "/auth"
+)
+
+// DefaultRotationHeader is the response header carrying rotated access tokens unless changed via WithRotationHeader
+const DefaultRotationHeader = "authorization"
+
+// DefaultTokenTTL is the assumed lifetime of access tokens if Personio does not report it, see WithTokenTTL
+const DefaultTokenTTL = time.Hour
+
+// ErrMalformedAccessToken is returned by NewClient for obviously invalid access tokens if WithTokenValidation is set
+var ErrMalformedAccessToken = errors.New("malformed personio access token")
+
+// Logger is the minimal logging interface used by Client, satisfied by *log.Logger
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Option configures optional behavior of a Client
+type Option func(*Client)
+
+// WithLogger sets the Logger receiving warnings about unexpected Personio API behavior
+func WithLogger(logger Logger) Option {
+	return func(personio *Client) {
+		personio.logger = logger
+	}
+}
+
+// WithRedaction enables or disables redaction of Personio error messages, which may contain attribute values, in errors and logs
+func WithRedaction(redact bool) Option {
+	return func(personio *Client) {
+		personio.redact = redact
+	}
+}
+
+// WithHTTPClient makes the Client use the specified http.Client instead of creating its own
+//
+// Options configuring the default http.Client (e.g. WithTLSConfig) are ignored, so is the timeout of NewClientWithTimeout
+func WithHTTPClient(client *http.Client) Option {
+	return func(personio *Client) {
+		personio.client = client
+	}
+}
+
+// WithTLSConfig sets the TLS configuration (e.g. minimum version or custom CAs) of the default http.Client's transport
+//
+// The option is ignored if a custom http.Client is supplied via WithHTTPClient
+func WithTLSConfig(config *tls.Config) Option {
+	return func(personio *Client) {
+		personio.tlsConfig = config
+	}
+}
+
+// WithMaxIdleConnsPerHost sets how many idle connections to Personio the default http.Client's transport keeps for reuse
+//
+// Raise it above Go's default of 2 when sending many requests concurrently. The option is ignored if a custom
+// http.Client is supplied via WithHTTPClient
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(personio *Client) {
+		personio.maxIdleConnsPerHost = n
+	}
+}
+
+// WithKeepAlive sets how long the default http.Client's transport keeps idle connections open for reuse
+//
+// A negative duration disables keep-alive, so every request opens a new connection. The option is ignored if a
+// custom http.Client is supplied via WithHTTPClient
+func WithKeepAlive(idleTimeout time.Duration) Option {
+	return func(personio *Client) {
+		personio.keepAlive = idleTimeout
+	}
+}
+
+// WithAuthContentType sets the Content-Type of authentication requests, e.g. to add a charset required by a proxy
+//
+// The body is always form-encoded, only the header value changes
+func WithAuthContentType(contentType string) Option {
+	return func(personio *Client) {
+		if contentType != "" {
+			personio.authContentType = contentType
+		}
+	}
+}
+
+// WithAuthEndpoint sets the HTTP method and path (relative to the base URL) of authentication requests, e.g. to point
+// the Client at an alternative auth gateway of a test environment
+//
+// POST requests send the credentials form-encoded in the body, other methods send them as query parameters.
+// Empty values keep the defaults DefaultAuthMethod and DefaultAuthPath.
+func WithAuthEndpoint(method string, path string) Option {
+	return func(personio *Client) {
+		if method != "" {
+			personio.authMethod = method
+		}
+		if path != "" {
+			personio.authPath = path
+		}
+	}
+}
+
+// WithRotationHeader sets the response header carrying rotated access tokens (default DefaultRotationHeader), e.g. for
+// proxies moving it to a custom header
+//
+// Header names are matched case-insensitively, so proxies changing the case of the header need no configuration.
+func WithRotationHeader(name string) Option {
+	return func(personio *Client) {
+		if name != "" {
+			personio.rotationHeader = name
+		}
+	}
+}
+
+// WithTokenTTL sets the assumed lifetime of access tokens if the auth response does not report it (default
+// DefaultTokenTTL)
+//
+// Idle tokens are dropped once expired, so the next request authenticates instead of being rejected first. Tokens
+// supplied via Credentials never expire this way.
+func WithTokenTTL(ttl time.Duration) Option {
+	return func(personio *Client) {
+		if ttl > 0 {
+			personio.tokenTTL = ttl
+		}
+	}
+}
+
+// WithNoRedirects makes the default http.Client return 3xx responses as errors instead of following redirects
+//
+// The option is ignored if a custom http.Client is supplied via WithHTTPClient
+func WithNoRedirects() Option {
+	return func(personio *Client) {
+		personio.noRedirects = true
+	}
+}
+
+// WithDefaultActiveOnly makes GetEmployees and GetEmployeesWithParams return only employees with status "active"
+//
+// Personio returns inactive and terminated employees too, which remains the default. Calls passing a "status"
+// query parameter to GetEmployeesWithParams are not filtered, neither are EmployeesChanged, GetEmployeesHiredBetween
+// and GetEmployeesSince, which have to see employees leaving.
+func WithDefaultActiveOnly(activeOnly bool) Option {
+	return func(personio *Client) {
+		personio.activeOnly = activeOnly
+	}
+}
+
+// WithMaxResults caps the number of time-offs GetTimeOffs accumulates across pages, protecting against runaway queries
+//
+// A cap of 0 (the default) disables it. See GetTimeOffsCapped for detecting truncated results.
+func WithMaxResults(n int) Option {
+	return func(personio *Client) {
+		personio.maxResults = n
+	}
+}
+
+// WithNumericBools makes the Client send booleans of request bodies (e.g. half-day flags of new time-offs) as 0/1
+//
+// Without this option booleans are sent as false/true
+func WithNumericBools() Option {
+	return func(personio *Client) {
+		personio.numericBools = true
+	}
+}
+
+// WithAttributeAliases makes attribute getters of fetched employees (including those embedded in time-offs) resolve
+// canonical keys through aliases, mapping canonical keys to the keys used by the tenant (e.g. "email" to "dynamic_123")
+//
+// A canonical key resolves to its own attribute if the aliased attribute is missing. Other access to Attributes,
+// e.g. by LabeledValues or MarshalStable, is not affected.
+func WithAttributeAliases(aliases map[string]string) Option {
+	return func(personio *Client) {
+		personio.attributeConfig.aliases = make(map[string]string, len(aliases))
+		for canonical, key := range aliases {
+			personio.attributeConfig.aliases[canonical] = key
+		}
+	}
+}
+
+// WithDateOnlyNormalization makes GetTimeAttribute of fetched employees (including those embedded in time-offs) return
+// dates as midnight in the location configured via WithLocation (UTC if none), e.g. to compare hire dates with ==
+//
+// The calendar day is taken as sent by Personio and the offset is dropped. Values not at midnight in their own offset
+// are timestamps (e.g. last_modified_at) rather than dates and are returned unchanged.
+func WithDateOnlyNormalization() Option {
+	return func(personio *Client) {
+		personio.normalizeDates = true
+	}
+}
+
+// applyAttributeConfig attaches the attribute configuration set via WithAttributeAliases or WithDateOnlyNormalization to the employee
+func (personio *Client) applyAttributeConfig(employee *Employee) {
+	if len(personio.attributeConfig.aliases) > 0 || personio.attributeConfig.dateLocation != nil {
+		employee.config = &personio.attributeConfig
+	}
+}
+
+// alias returns the key the canonical key is aliased to, if any
+func (config *attributeConfig) alias(key string) (string, bool) {
+	if config == nil {
+		return "", false
+	}
+	alias, ok := config.aliases[key]
+	return alias, ok
+}
+
+// WithLocation sets the location calendar days are evaluated in, e.g. the company's time zone
+//
+// Without this option dates are evaluated in the location of the passed time.Time values
+func WithLocation(loc *time.Location) Option {
+	return func(personio *Client) {
+		personio.location = loc
+	}
+}
+
+// WithTokenValidation makes NewClient fail fast if the access token supplied in Credentials is obviously malformed
+//
+// Tokens consisting only of whitespace, carrying a "Bearer " prefix or containing whitespace are rejected with
+// ErrMalformedAccessToken instead of being sent and rejected by Personio with 401. Credentials without access token
+// are not affected.
+func WithTokenValidation() Option {
+	return func(personio *Client) {
+		personio.validateToken = true
+	}
+}
+
+// validateAccessToken checks the format of a statically supplied access token, the token is not part of the error
+func validateAccessToken(token string) error {
+
+	trimmed := strings.TrimSpace(token)
+	switch {
+	case trimmed == "":
+		return fmt.Errorf("%w: empty after trimming whitespace", ErrMalformedAccessToken)
+	case len(trimmed) >= 7 && strings.EqualFold(trimmed[:7], "Bearer "):
+		return fmt.Errorf("%w: must not include the \"Bearer \" prefix", ErrMalformedAccessToken)
+	case strings.ContainsAny(token, " \t\r\n"):
+		return fmt.Errorf("%w: contains whitespace", ErrMalformedAccessToken)
+	}
+
+	return nil
+}
+
+// newDefaultHttpClient creates the http.Client used if no custom one is supplied
+func (personio *Client) newDefaultHttpClient(timeout time.Duration) *http.Client {
+
+	client := &http.Client{Timeout: timeout}
+
+	if personio.tlsConfig != nil || personio.maxIdleConnsPerHost > 0 || personio.keepAlive != 0 {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if personio.tlsConfig != nil {
+			transport.TLSClientConfig = personio.tlsConfig.Clone()
+		}
+		if personio.maxIdleConnsPerHost > 0 {
+			transport.MaxIdleConnsPerHost = personio.maxIdleConnsPerHost
+			if transport.MaxIdleConns > 0 && transport.MaxIdleConns < personio.maxIdleConnsPerHost {
+				transport.MaxIdleConns = personio.maxIdleConnsPerHost
+			}
+		}
+		if personio.keepAlive < 0 {
+			transport.DisableKeepAlives = true
+		} else if personio.keepAlive > 0 {
+			transport.IdleConnTimeout = personio.keepAlive
+		}
+		client.Transport = transport
+	}
+
+	if personio.noRedirects {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	return client
+}
+
+// logf writes a message to the configured Logger, if any
+func (personio *Client) logf(format string, v ...interface{}) {
+	if personio.logger != nil {
+		personio.logger.Printf(format, v...)
+	}
+}
@@ -0,0 +1,524 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type tlsConfigTestCase struct {
+	opts     []Option
+	wantFail bool
+}
+
+func TestClient_WithTLSConfig(t *testing.T) {
+
+	mock := &PersonioMock{}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(mock.PersonioMockHandler))
+	// failing handshakes are expected
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	trusted := x509.NewCertPool()
+	trusted.AddCert(server.Certificate())
+
+	tlsCases := []tlsConfigTestCase{
+		{opts: nil, wantFail: true},
+		{opts: []Option{WithTLSConfig(&tls.Config{RootCAs: trusted, MinVersion: tls.VersionTLS12})}, wantFail: false},
+		{opts: []Option{WithTLSConfig(&tls.Config{RootCAs: trusted, MinVersion: tls.VersionTLS12, MaxVersion: tls.VersionTLS11})}, wantFail: true},
+		// ignored when a custom client is supplied
+		{opts: []Option{WithTLSConfig(&tls.Config{RootCAs: trusted}), WithHTTPClient(&http.Client{})}, wantFail: true},
+		{opts: []Option{WithTLSConfig(&tls.Config{}), WithHTTPClient(server.Client())}, wantFail: false},
+	}
+
+	for testNumber, testCase := range tlsCases {
+		personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+		personio, err := NewClient(context.TODO(), server.URL, personioCredentials, testCase.opts...)
+		if err != nil {
+			t.Errorf("[%d] Failed to create Personio API v1 client: %s", testNumber, err)
+			continue
+		}
+
+		_, err = personio.Authenticate("abc", "def")
+		if (err != nil) != testCase.wantFail {
+			t.Errorf("[%d] Expected failure %t, got error: %v", testNumber, testCase.wantFail, err)
+		}
+	}
+}
+
+func TestClient_WithAuthContentType(t *testing.T) {
+
+	testCases := []struct {
+		opts []Option
+		want string
+	}{
+		{opts: nil, want: "application/x-www-form-urlencoded"},
+		{opts: []Option{WithAuthContentType("")}, want: "application/x-www-form-urlencoded"},
+		{opts: []Option{WithAuthContentType("application/x-www-form-urlencoded; charset=utf-8")}, want: "application/x-www-form-urlencoded; charset=utf-8"},
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	for testCaseNumber, testCase := range testCases {
+
+		personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+		personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, testCase.opts...)
+		if err != nil {
+			t.Errorf("[%d] Failed to create Personio API v1 client: %s", testCaseNumber, err)
+			continue
+		}
+
+		_, err = personio.Authenticate("abc", "def")
+		if err != nil {
+			t.Errorf("[%d] Failed to authenticate: %s", testCaseNumber, err)
+			continue
+		}
+
+		if server.mock.lastAuthContentType != testCase.want {
+			t.Errorf("[%d] Expected Content-Type %s, got %s", testCaseNumber, testCase.want, server.mock.lastAuthContentType)
+		}
+	}
+}
+
+func TestClient_WithAuthEndpoint(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	// the mock also accepts GET /gateway/token with credentials in the query
+	var debug bytes.Buffer
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithAuthEndpoint(http.MethodGet, "/gateway/token"), WithDebugWriter(&debug))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	employee, err := personio.GetEmployee(6205887)
+	if err != nil {
+		t.Errorf("Failed to get employee: %s", err)
+		return
+	}
+	if employee == nil {
+		t.Errorf("Expected employee, got nil")
+	}
+	if server.mock.authCount != 1 {
+		t.Errorf("Expected 1 authentication, got %d", server.mock.authCount)
+	}
+
+	output := debug.String()
+	if !strings.Contains(output, "> GET /gateway/token?") {
+		t.Errorf("Expected GET /gateway/token in debug output, got:\n%s", output)
+	}
+	if strings.Contains(output, "client_secret=def") || strings.Contains(output, "\"token\"") {
+		t.Errorf("Expected credentials and token to be masked, got:\n%s", output)
+	}
+
+	// POST to an unknown path fails
+	personio, err = NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithAuthEndpoint("", "/gateway/token"))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+	_, err = personio.Authenticate("abc", "def")
+	if err == nil {
+		t.Errorf("Expected error authenticating via POST /gateway/token, got nil")
+	}
+
+	// transport errors must not leak the credentials sent in the query
+	closed, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+	_ = closed.Close()
+	personio, err = NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", closed.port), personioCredentials, WithAuthEndpoint(http.MethodGet, "/gateway/token"))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+	_, err = personio.Authenticate("abc", "def")
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		t.Fatalf("Expected *url.Error authenticating against a closed server, got %v", err)
+	}
+	if strings.Contains(err.Error(), "client_secret=def") || !strings.Contains(err.Error(), "client_id=abc") {
+		t.Errorf("Expected client secret to be masked, got %s", err)
+	}
+}
+
+func TestClient_WithNoRedirects(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/auth":
+			_, _ = io.WriteString(w, "{\"success\": true, \"data\": { \"token\": \"ghi\" } }")
+		case "/company/employees/6205887":
+			http.Redirect(w, req, "/elsewhere", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		opts       []Option
+		wantStatus int
+	}{
+		{opts: nil, wantStatus: http.StatusNotFound},
+		{opts: []Option{WithNoRedirects()}, wantStatus: http.StatusFound},
+		{opts: []Option{WithNoRedirects(), WithHTTPClient(&http.Client{})}, wantStatus: http.StatusNotFound},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+
+		personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+		personio, err := NewClient(context.TODO(), server.URL, personioCredentials, testCase.opts...)
+		if err != nil {
+			t.Errorf("[%d] Failed to create Personio API v1 client: %s", testCaseNumber, err)
+			continue
+		}
+
+		_, err = personio.GetEmployee(6205887)
+		var statusErr StatusError
+		if !errors.As(err, &statusErr) || statusErr.Code != testCase.wantStatus {
+			t.Errorf("[%d] Expected status %d, got %v", testCaseNumber, testCase.wantStatus, err)
+		}
+	}
+}
+
+func TestClient_WithRotationHeader(t *testing.T) {
+
+	testCases := []struct {
+		header string
+		opts   []Option
+	}{
+		// header keys are sent as is, bypassing canonicalization
+		{header: "AUTHORIZATION"},
+		{header: "authorization"},
+		{header: "X-ROTATED-TOKEN", opts: []Option{WithRotationHeader("x-rotated-token")}},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+
+		authCount := 0
+		issued := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			switch req.URL.Path {
+			case "/auth":
+				authCount++
+				issued++
+				_, _ = fmt.Fprintf(w, "{\"success\": true, \"data\": { \"token\": \"token-%d\" } }", issued)
+			case "/company/employees/6205887":
+				if req.Header.Get("Authorization") != fmt.Sprintf("Bearer token-%d", issued) {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				issued++
+				w.Header()[testCase.header] = []string{fmt.Sprintf("Bearer token-%d", issued)}
+				_, _ = io.WriteString(w, "{\"success\": true, \"data\": { \"type\": \"Employee\", \"attributes\": {} } }")
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+		personio, err := NewClient(context.TODO(), server.URL, personioCredentials, testCase.opts...)
+		if err != nil {
+			t.Errorf("[%d] Failed to create Personio API v1 client: %s", testCaseNumber, err)
+			server.Close()
+			continue
+		}
+
+		for i := 0; i < 3; i++ {
+			_, err = personio.GetEmployee(6205887)
+			if err != nil {
+				t.Errorf("[%d] Failed to get employee: %s", testCaseNumber, err)
+			}
+		}
+		server.Close()
+
+		// rotated tokens are picked up, so only the first request authenticates
+		if authCount != 1 {
+			t.Errorf("[%d] Expected 1 authentication, got %d", testCaseNumber, authCount)
+		}
+	}
+}
+
+func TestClient_WithTokenValidation(t *testing.T) {
+
+	testCases := []struct {
+		token    string
+		opts     []Option
+		wantFail bool
+	}{
+		{token: "Bearer ghi", opts: []Option{WithTokenValidation()}, wantFail: true},
+		{token: "bearer ghi", opts: []Option{WithTokenValidation()}, wantFail: true},
+		{token: "  ", opts: []Option{WithTokenValidation()}, wantFail: true},
+		{token: "ghi jkl", opts: []Option{WithTokenValidation()}, wantFail: true},
+		{token: "ghi", opts: []Option{WithTokenValidation()}, wantFail: false},
+		// no static token
+		{token: "", opts: []Option{WithTokenValidation()}, wantFail: false},
+		// not validated without the option
+		{token: "Bearer ghi", opts: nil, wantFail: false},
+	}
+
+	for testNumber, testCase := range testCases {
+		personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def", AccessToken: testCase.token}
+		_, err := NewClient(context.TODO(), "http://localhost", personioCredentials, testCase.opts...)
+		if (err != nil) != testCase.wantFail {
+			t.Errorf("[%d] Expected failure %t, got error: %v", testNumber, testCase.wantFail, err)
+			continue
+		}
+		if err != nil && !errors.Is(err, ErrMalformedAccessToken) {
+			t.Errorf("[%d] Expected ErrMalformedAccessToken, got %v", testNumber, err)
+		}
+		if err != nil && strings.Contains(err.Error(), "ghi") {
+			t.Errorf("[%d] Expected error not to contain the token, got %v", testNumber, err)
+		}
+	}
+}
+
+func TestClient_WithMaxResults(t *testing.T) {
+
+	testCases := []struct {
+		maxResults    int
+		limit         int
+		wantCount     int
+		wantTruncated bool
+	}{
+		{maxResults: 0, limit: intMax, wantCount: 3, wantTruncated: false},
+		{maxResults: 2, limit: intMax, wantCount: 2, wantTruncated: true},
+		{maxResults: 3, limit: intMax, wantCount: 3, wantTruncated: false},
+		{maxResults: 5, limit: intMax, wantCount: 3, wantTruncated: false},
+		// limit below the cap
+		{maxResults: 2, limit: 1, wantCount: 1, wantTruncated: false},
+	}
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	for testNumber, testCase := range testCases {
+		server, err := newTestServer()
+		if err != nil {
+			t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+			return
+		}
+
+		logger := &testLogger{}
+		personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithMaxResults(testCase.maxResults), WithLogger(logger))
+		if err != nil {
+			_ = server.Close()
+			t.Errorf("[%d] Failed to create Personio API v1 client: %s", testNumber, err)
+			continue
+		}
+
+		timeOffs, truncated, err := personio.GetTimeOffsCapped(nil, nil, 0, testCase.limit)
+		if err != nil {
+			t.Errorf("[%d] Failed to query time-offs: %s", testNumber, err)
+		} else if len(timeOffs) != testCase.wantCount || truncated != testCase.wantTruncated {
+			t.Errorf("[%d] Expected %d time-offs (truncated %t), got %d (truncated %t)", testNumber, testCase.wantCount, testCase.wantTruncated, len(timeOffs), truncated)
+		}
+
+		timeOffs, err = personio.GetTimeOffs(nil, nil, 0, testCase.limit)
+		_ = server.Close()
+		if err != nil {
+			t.Errorf("[%d] Failed to query time-offs: %s", testNumber, err)
+			continue
+		}
+		if len(timeOffs) != testCase.wantCount {
+			t.Errorf("[%d] Expected %d time-offs, got %d", testNumber, testCase.wantCount, len(timeOffs))
+		}
+		if (len(logger.messages) == 1) != testCase.wantTruncated {
+			t.Errorf("[%d] Expected truncation to be logged %t, got %v", testNumber, testCase.wantTruncated, logger.messages)
+		}
+	}
+}
+
+func TestClient_WithConnectionPool(t *testing.T) {
+
+	testCases := []struct {
+		opts            []Option
+		wantDefault     bool
+		wantIdlePerHost int
+		wantIdleTimeout time.Duration
+		wantNoKeepAlive bool
+	}{
+		{opts: nil, wantDefault: true},
+		{opts: []Option{WithMaxIdleConnsPerHost(32)}, wantIdlePerHost: 32, wantIdleTimeout: 90 * time.Second},
+		{opts: []Option{WithMaxIdleConnsPerHost(200), WithKeepAlive(5 * time.Minute)}, wantIdlePerHost: 200, wantIdleTimeout: 5 * time.Minute},
+		{opts: []Option{WithKeepAlive(-1)}, wantIdlePerHost: 0, wantIdleTimeout: 90 * time.Second, wantNoKeepAlive: true},
+		// ignored when a custom client is supplied
+		{opts: []Option{WithMaxIdleConnsPerHost(32), WithHTTPClient(&http.Client{})}, wantDefault: true},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+		personio, err := NewClient(context.TODO(), "http://localhost", personioCredentials, testCase.opts...)
+		if err != nil {
+			t.Errorf("[%d] Failed to create Personio API v1 client: %s", testCaseNumber, err)
+			continue
+		}
+
+		if testCase.wantDefault {
+			if personio.client.Transport != nil {
+				t.Errorf("[%d] Expected default transport, got %v", testCaseNumber, personio.client.Transport)
+			}
+			continue
+		}
+
+		transport, ok := personio.client.Transport.(*http.Transport)
+		if !ok {
+			t.Errorf("[%d] Expected custom transport, got %v", testCaseNumber, personio.client.Transport)
+			continue
+		}
+		if transport.MaxIdleConnsPerHost != testCase.wantIdlePerHost || transport.MaxIdleConns < transport.MaxIdleConnsPerHost {
+			t.Errorf("[%d] Expected %d idle connections per host, got %d (total %d)", testCaseNumber, testCase.wantIdlePerHost, transport.MaxIdleConnsPerHost, transport.MaxIdleConns)
+		}
+		if transport.IdleConnTimeout != testCase.wantIdleTimeout || transport.DisableKeepAlives != testCase.wantNoKeepAlive {
+			t.Errorf("[%d] Expected idle timeout %s (keep-alive disabled %t), got %s (%t)", testCaseNumber, testCase.wantIdleTimeout, testCase.wantNoKeepAlive, transport.IdleConnTimeout, transport.DisableKeepAlives)
+		}
+	}
+}
+
+func TestClient_WithAttributeAliases(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	// this tenant keeps nicknames in first_name and has no separate work email attribute
+	aliases := map[string]string{"nickname": "first_name", "email": "dynamic_999999"}
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithAttributeAliases(aliases))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+	aliases["nickname"] = "last_name"
+
+	employee, err := personio.GetEmployee(6205887)
+	if err != nil {
+		t.Fatalf("Failed to query employee: %s", err)
+	}
+	employees, err := personio.GetEmployees()
+	if err != nil {
+		t.Fatalf("Failed to query employees: %s", err)
+	}
+	timeOffs, err := personio.GetTimeOffs(nil, nil, 0, intMax)
+	if err != nil {
+		t.Fatalf("Failed to query time-offs: %s", err)
+	}
+
+	absences, err := personio.GetAbsences(nil, nil, 0, intMax)
+	if err != nil {
+		t.Fatalf("Failed to query absences: %s", err)
+	}
+	absenceTimeOff := absences[0].ToTimeOff()
+
+	resolved := []*Employee{employee, employees[0], &timeOffs[1].Employee, &absences[0].Employee, &absenceTimeOff.Employee}
+	for i, employee := range resolved {
+		if nickname := employee.GetStringAttribute("nickname"); nickname == nil || *nickname != "El" {
+			t.Errorf("[%d] Expected nickname El via alias, got %v", i, nickname)
+		}
+		if email := employee.GetStringAttribute("email"); email == nil || *email != "gonzo@giantswarm.io" {
+			t.Errorf("[%d] Expected email to fall back to the canonical key, got %v", i, email)
+		}
+		if _, ok := employee.Attributes["nickname"]; ok {
+			t.Errorf("[%d] Expected aliases not to add attributes", i)
+		}
+	}
+
+	unaliased := Employee{AttributeContainer: AttributeContainer{Attributes: employee.Attributes}}
+	if nickname := unaliased.GetStringAttribute("nickname"); nickname != nil {
+		t.Errorf("Expected no nickname without aliases, got %s", *nickname)
+	}
+}
+
+func TestClient_WithDateOnlyNormalization(t *testing.T) {
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Failed to load location: %s", err)
+	}
+
+	testCases := []struct {
+		opts         []Option
+		alias        string
+		wantHireDate time.Time
+	}{
+		// El Gonzo's hire_date is sent as 2022-01-12T00:00:00+01:00
+		{opts: nil, wantHireDate: makeTime("2022-01-12T00:00:00+01:00")},
+		{opts: []Option{WithDateOnlyNormalization()}, wantHireDate: time.Date(2022, time.January, 12, 0, 0, 0, 0, time.UTC)},
+		{opts: []Option{WithDateOnlyNormalization(), WithLocation(berlin)}, wantHireDate: time.Date(2022, time.January, 12, 0, 0, 0, 0, berlin)},
+		{opts: []Option{WithDateOnlyNormalization(), WithAttributeAliases(map[string]string{"start_date": "hire_date"})}, alias: "start_date", wantHireDate: time.Date(2022, time.January, 12, 0, 0, 0, 0, time.UTC)},
+	}
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	for testCaseNumber, testCase := range testCases {
+		server, err := newTestServer()
+		if err != nil {
+			t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+			return
+		}
+
+		personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, testCase.opts...)
+		if err != nil {
+			_ = server.Close()
+			t.Errorf("[%d] Failed to create Personio API v1 client: %s", testCaseNumber, err)
+			continue
+		}
+
+		employee, err := personio.GetEmployee(6205887)
+		_ = server.Close()
+		if err != nil {
+			t.Errorf("[%d] Failed to query employee: %s", testCaseNumber, err)
+			continue
+		}
+
+		hireDate := employee.GetTimeAttribute("hire_date")
+		if hireDate == nil || *hireDate != testCase.wantHireDate {
+			t.Errorf("[%d] Expected hire date %s, got %v", testCaseNumber, testCase.wantHireDate, hireDate)
+		}
+		// timestamps are not dates
+		if modified := employee.GetTimeAttribute("last_modified_at"); modified == nil || !modified.Equal(makeTime("2022-11-29T10:11:52+01:00")) {
+			t.Errorf("[%d] Expected last modification at 2022-11-29T10:11:52+01:00, got %v", testCaseNumber, modified)
+		}
+		if testCase.alias != "" {
+			if aliased := employee.GetTimeAttribute(testCase.alias); aliased == nil || *aliased != testCase.wantHireDate {
+				t.Errorf("[%d] Expected aliased hire date %s, got %v", testCaseNumber, testCase.wantHireDate, aliased)
+			}
+		}
+	}
+}
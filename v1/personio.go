@@ -1,15 +1,24 @@
 package v1
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	util "github.com/giantswarm/personio-go"
@@ -17,11 +26,49 @@ import (
 
 const DefaultBaseUrl = "https://api.personio.de/v1"
 
+// pagingMaxLimit is the maximum page size of Personio's list endpoints unless listed in pagingMaxLimits
 const pagingMaxLimit = 100
 
+// pagingMaxLimits are the maximum page sizes of list endpoints deviating from pagingMaxLimit
+var pagingMaxLimits = map[string]int{
+	"/company/attendances": 200,
+}
+
+// maxPageSize returns the maximum page size of the list endpoint at relpath
+func maxPageSize(relpath string) int {
+	if limit, ok := pagingMaxLimits[strings.TrimSuffix(relpath, "/")]; ok {
+		return limit
+	}
+	return pagingMaxLimit
+}
+
 const intMax = 2147483647
 
-const queryDateFormat = "2006-01-02"
+const redactedMessage = "[redacted]"
+
+// RequestIdHeader is the header carrying the correlation ID of a request
+const RequestIdHeader = "X-Request-ID"
+
+// requestIdKey is the context key of the request ID
+type requestIdKey struct{}
+
+// ContextWithRequestId returns a context making the client send the specified correlation ID with its requests
+func ContextWithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+// newRequestId returns the correlation ID from the context or a random one
+func newRequestId(ctx context.Context) string {
+	if ctx != nil {
+		if requestId, ok := ctx.Value(requestIdKey{}).(string); ok && requestId != "" {
+			return requestId
+		}
+	}
+
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
 
 // Error is an error with an associated status code
 type Error interface {
@@ -31,12 +78,16 @@ type Error interface {
 
 // StatusError represents an error with an associated HTTP status code
 type StatusError struct {
-	Err  error
-	Code int
+	Err       error
+	Code      int
+	RequestId string
 }
 
 // Allows StatusError to satisfy the error interface
 func (s StatusError) Error() string {
+	if s.RequestId != "" {
+		return fmt.Sprintf("%s (request id %s)", s.Err.Error(), s.RequestId)
+	}
 	return s.Err.Error()
 }
 
@@ -45,9 +96,68 @@ func (s StatusError) Status() int {
 	return s.Code
 }
 
-// PersonioBool is a custom boolean that can be unmarshalled from 0/1 and false/true
+// Unwrap returns the underlying error
+func (s StatusError) Unwrap() error {
+	return s.Err
+}
+
+// PersonioError is an error reported by Personio in the response body ({"success": false, "error": {...}})
+//
+// Message is redacted if WithRedaction is set
+type PersonioError struct {
+	Code      int
+	Message   string
+	RequestId string
+}
+
+// Allows PersonioError to satisfy the error interface
+func (p PersonioError) Error() string {
+	return fmt.Sprintf("personio returned error: code=%d, message=%s, request id=%s", p.Code, p.Message, p.RequestId)
+}
+
+// authErrorCodes are the codes of PersonioError reporting invalid or expired access tokens
+var authErrorCodes = map[int]bool{
+	http.StatusUnauthorized: true,
+}
+
+// IsAuthError returns whether err reports an invalid or expired access token, either by HTTP status 401 or by an
+// error code of Personio's response body
+func IsAuthError(err error) bool {
+	var personioErr PersonioError
+	if errors.As(err, &personioErr) && authErrorCodes[personioErr.Code] {
+		return true
+	}
+	var statusErr StatusError
+	return errors.As(err, &statusErr) && statusErr.Code == http.StatusUnauthorized
+}
+
+// PersonioBool is a custom boolean that can be unmarshalled from 0/1 and false/true, it's marshalled as false/true
 type PersonioBool bool
 
+// MarshalJSON encodes the boolean as false/true
+func (bit PersonioBool) MarshalJSON() ([]byte, error) {
+	if bit {
+		return []byte("true"), nil
+	}
+	return []byte("false"), nil
+}
+
+// NumericBool is a PersonioBool marshalled as 0/1 for endpoints requiring numeric booleans
+type NumericBool PersonioBool
+
+// MarshalJSON encodes the boolean as 0/1
+func (bit NumericBool) MarshalJSON() ([]byte, error) {
+	if bit {
+		return []byte("1"), nil
+	}
+	return []byte("0"), nil
+}
+
+// UnmarshalJSON decodes the boolean from 0/1 and false/true
+func (bit *NumericBool) UnmarshalJSON(data []byte) error {
+	return (*PersonioBool)(bit).UnmarshalJSON(data)
+}
+
 func (bit *PersonioBool) UnmarshalJSON(data []byte) error {
 	asString := string(data)
 	if asString == "1" || asString == "true" {
@@ -71,23 +181,40 @@ type resultBody struct {
 }
 
 // Auth is the response body of /auth
+//
+// ExpiresIn (seconds) and ExpiresAt (Unix time) are only set if Personio reports the token's lifetime
 type Auth struct {
 	Data struct {
-		Token string `json:"token"`
+		Token     string `json:"token"`
+		ExpiresIn int64  `json:"expires_in,omitempty"`
+		ExpiresAt int64  `json:"expires_at,omitempty"`
 	} `json:"data,omitempty"`
 }
 
+// Expiry returns when the token expires, preferring ExpiresAt over ExpiresIn (relative to now) and falling back to
+// now plus ttl if Personio reported neither
+func (a *Auth) Expiry(now time.Time, ttl time.Duration) time.Time {
+	if a.Data.ExpiresAt > 0 {
+		return time.Unix(a.Data.ExpiresAt, 0)
+	}
+	if a.Data.ExpiresIn > 0 {
+		return now.Add(time.Duration(a.Data.ExpiresIn) * time.Second)
+	}
+	return now.Add(ttl)
+}
+
 // Attribute is a nested Personio API v1 attribute (they have variable type and are configurable)
 type Attribute struct {
 	Label       string      `json:"label"`
 	Value       interface{} `json:"value"`
 	Type        string      `json:"type"`
 	UniversalId string      `json:"universal_id"`
+	Currency    string      `json:"currency,omitempty"`
 }
 
 // GetIntValue returns a pointer to the attributes value as an int64 or nil if no such value is available
 func (a *Attribute) GetIntValue() *int64 {
-	if a.Type == "integer" && a.Value != nil {
+	if a != nil && a.Type == "integer" && a.Value != nil {
 		switch a.Value.(type) {
 		case float64:
 			value := int64(a.Value.(float64))
@@ -99,7 +226,7 @@ func (a *Attribute) GetIntValue() *int64 {
 
 // GetFloatValue returns a pointer to the attributes value as an float64 or nil if no such value is available
 func (a *Attribute) GetFloatValue() *float64 {
-	if (a.Type == "integer" || a.Type == "decimal") && a.Value != nil {
+	if a != nil && (a.Type == "integer" || a.Type == "decimal") && a.Value != nil {
 		switch a.Value.(type) {
 		case float64:
 			value := a.Value.(float64)
@@ -111,7 +238,7 @@ func (a *Attribute) GetFloatValue() *float64 {
 
 // GetStringValue returns a pointer to the attributes value as string or nil if no such value is available
 func (a *Attribute) GetStringValue() *string {
-	if (a.Type == "standard" || a.Type == "multiline" || a.Type == "list") && a.Value != nil {
+	if a != nil && (a.Type == "standard" || a.Type == "multiline" || a.Type == "list") && a.Value != nil {
 		switch a.Value.(type) {
 		case string:
 			value := a.Value.(string)
@@ -122,26 +249,60 @@ func (a *Attribute) GetStringValue() *string {
 }
 
 // GetTagValues returns the attributes value as string slice or nil if no such value is available
+//
+// Values may be encoded as comma-separated string, as array of strings or as array of tag objects ({"id", "name"}),
+// in which case the tag names are returned
 func (a *Attribute) GetTagValues() []string {
-	if a.Type == "tags" && a.Value != nil {
+	if a != nil && a.Type == "tags" && a.Value != nil {
 		switch a.Value.(type) {
 		case string:
 			value := strings.FieldsFunc(a.Value.(string), func(char rune) bool { return char == ',' })
 			return value
+		case []interface{}:
+			elements := a.Value.([]interface{})
+			value := make([]string, 0, len(elements))
+			for _, element := range elements {
+				if name := tagName(element); name != "" {
+					value = append(value, name)
+				}
+			}
+			return value
 		}
 	}
 	return nil
 }
 
+// tagName returns the name of a single tag element, which may be a plain string or a (typed) tag object
+func tagName(element interface{}) string {
+	switch element.(type) {
+	case string:
+		return element.(string)
+	case map[string]interface{}:
+		tag := element.(map[string]interface{})
+		if attributes, ok := tag["attributes"].(map[string]interface{}); ok {
+			tag = attributes
+		}
+		name, _ := tag["name"].(string)
+		return name
+	}
+	return ""
+}
+
 // GetTimeValue returns a pointer to the attributes value as time.Time or nil if no such value is available
+//
+// Values may be encoded as RFC3339 timestamp or as bare date (YYYY-MM-DD, interpreted as midnight UTC)
 func (a *Attribute) GetTimeValue() *time.Time {
-	if a.Type == "date" && a.Value != nil {
+	if a != nil && a.Type == "date" && a.Value != nil {
 		switch a.Value.(type) {
 		case string:
 			value, err := time.Parse(time.RFC3339, a.Value.(string))
 			if err == nil {
 				return &value
 			}
+			value, err = util.ParsePersonioDate(a.Value.(string))
+			if err == nil {
+				return &value
+			}
 		case time.Time:
 			value := a.Value.(time.Time)
 			return &value
@@ -152,7 +313,7 @@ func (a *Attribute) GetTimeValue() *time.Time {
 
 // GetMapValue returns a pointer to the embedded objects attributes as map or nil if no such value is available
 func (a *Attribute) GetMapValue() map[string]interface{} {
-	if a.Type == "standard" && a.Value != nil {
+	if a != nil && a.Type == "standard" && a.Value != nil {
 		nested, _ := a.Value.(map[string]interface{})
 		nestedAttributes, _ := nested["attributes"].(map[string]interface{})
 		return nestedAttributes
@@ -160,45 +321,288 @@ func (a *Attribute) GetMapValue() map[string]interface{} {
 	return map[string]interface{}{}
 }
 
+// GetListOfMapsValue returns the attributes of each embedded object of an array value, e.g. of repeating profile sections
+//
+// Elements may be typed objects ({"type", "attributes"}) or plain objects, other elements are skipped. An empty slice is
+// returned if no such value is available.
+func (a *Attribute) GetListOfMapsValue() []map[string]interface{} {
+	if a == nil || a.Value == nil {
+		return []map[string]interface{}{}
+	}
+
+	elements, _ := a.Value.([]interface{})
+	value := make([]map[string]interface{}, 0, len(elements))
+	for _, element := range elements {
+		nested, ok := element.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if nestedAttributes, ok := nested["attributes"].(map[string]interface{}); ok {
+			nested = nestedAttributes
+		}
+		value = append(value, nested)
+	}
+	return value
+}
+
+// guessIntPattern and guessFloatPattern match the strings GuessValue converts to numbers
+var (
+	guessIntPattern   = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+	guessFloatPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)\.[0-9]+$`)
+)
+
+// GuessValue returns the attributes value as best-effort Go value regardless of its declared type
+//
+// Conversions are tried in this order, the first one succeeding wins:
+//  1. int64 for integral numbers and plain decimal integer strings ("42", not "042" or "+42")
+//  2. float64 for other numbers and plain decimal strings ("0.5", not "NaN", "Inf" or "1e3")
+//  3. time.Time for time values, RFC3339 timestamps and Personio dates (YYYY-MM-DD)
+//  4. bool for booleans and the strings "true" and "false"
+//  5. []string for arrays (tag objects yield their names) and comma-separated strings of "tags" attributes
+//
+// Otherwise the raw value is returned, nested objects unchanged.
+func (a *Attribute) GuessValue() interface{} {
+	if a == nil || a.Value == nil {
+		return nil
+	}
+
+	switch a.Value.(type) {
+	case float64:
+		value := a.Value.(float64)
+		if value == math.Trunc(value) && math.Abs(value) < 1<<63 {
+			return int64(value)
+		}
+		return value
+	case string:
+		// strings such as zip codes with leading zeros or a first name "Nan" must stay strings
+		value := strings.TrimSpace(a.Value.(string))
+		if guessIntPattern.MatchString(value) {
+			if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+				return intValue
+			}
+		}
+		if guessFloatPattern.MatchString(value) {
+			if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+				return floatValue
+			}
+		}
+		if timeValue, err := time.Parse(time.RFC3339, value); err == nil {
+			return timeValue
+		}
+		if timeValue, err := util.ParsePersonioDate(value); err == nil {
+			return timeValue
+		}
+		if value == "true" || value == "false" {
+			return value == "true"
+		}
+		if a.Type == "tags" {
+			return (&Attribute{Type: "tags", Value: value}).GetTagValues()
+		}
+	case []interface{}:
+		return (&Attribute{Type: "tags", Value: a.Value}).GetTagValues()
+	}
+
+	return a.Value
+}
+
 // AttributeContainer is something that has object attributes of the elaborate and/or dynamic kind
 type AttributeContainer struct {
 	Attributes map[string]Attribute `json:"attributes"`
+
+	// order is the order of the attribute keys in the JSON the container was unmarshalled from
+	order []string
+
+	// config is the attribute configuration of the Client the container was fetched by, nil if none
+	config *attributeConfig
+}
+
+// attributeConfig configures how attribute getters resolve keys and values
+//
+// aliases maps canonical attribute keys to the keys used instead, see WithAttributeAliases.
+// dateLocation is the location dates are normalized to midnight in, nil if they are returned as is.
+type attributeConfig struct {
+	aliases      map[string]string
+	dateLocation *time.Location
+}
+
+// objectKeys returns the keys of the JSON object in data in their original order (first occurrence), nil for null
+func objectKeys(data []byte) ([]string, error) {
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, nil
+	}
+
+	var keys []string
+	seen := map[string]bool{}
+	for decoder.More() {
+		token, err = decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+
+		var value json.RawMessage
+		err = decoder.Decode(&value)
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+// UnmarshalJSON decodes the attributes while recording their original order for OrderedKeys
+func (ac *AttributeContainer) UnmarshalJSON(data []byte) error {
+
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil
+	}
+
+	var container struct {
+		Attributes map[string]Attribute `json:"attributes"`
+	}
+	err := json.Unmarshal(data, &container)
+	if err != nil {
+		return err
+	}
+
+	var raw struct {
+		Attributes json.RawMessage `json:"attributes"`
+	}
+	err = json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	var order []string
+	if len(raw.Attributes) > 0 {
+		order, err = objectKeys(raw.Attributes)
+		if err != nil {
+			return err
+		}
+	}
+
+	ac.Attributes = container.Attributes
+	ac.order = order
+	return nil
+}
+
+// OrderedKeys returns the attribute keys in the order Personio sent them
+//
+// Keys added after unmarshalling (or all keys of containers not unmarshalled from JSON) follow in alphabetical order,
+// removed keys are skipped
+func (ac *AttributeContainer) OrderedKeys() []string {
+	if ac == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(ac.Attributes))
+	listed := make(map[string]bool, len(ac.order))
+	for _, key := range ac.order {
+		if _, ok := ac.Attributes[key]; ok {
+			keys = append(keys, key)
+			listed[key] = true
+		}
+	}
+
+	var remaining []string
+	for key := range ac.Attributes {
+		if !listed[key] {
+			remaining = append(remaining, key)
+		}
+	}
+	sort.Strings(remaining)
+
+	return append(keys, remaining...)
+}
+
+// attribute returns the specified attribute or nil if the container or the attribute is missing
+//
+// Keys with an alias resolve to the aliased attribute if it exists
+func (ac *AttributeContainer) attribute(key string) *Attribute {
+	if ac == nil {
+		return nil
+	}
+	if alias, ok := ac.config.alias(key); ok {
+		if attr, ok := ac.Attributes[alias]; ok {
+			return &attr
+		}
+	}
+	attr, ok := ac.Attributes[key]
+	if !ok {
+		return nil
+	}
+	return &attr
 }
 
 // GetIntAttribute returns a pointer to the specified attributes value as int64 or nil
 func (ac *AttributeContainer) GetIntAttribute(key string) *int64 {
-	attr := ac.Attributes[key]
-	return attr.GetIntValue()
+	return ac.attribute(key).GetIntValue()
 }
 
 // GetFloatAttribute returns a pointer to the specified attributes value as float64 or nil
 func (ac *AttributeContainer) GetFloatAttribute(key string) *float64 {
-	attr := ac.Attributes[key]
-	return attr.GetFloatValue()
+	return ac.attribute(key).GetFloatValue()
 }
 
 // GetStringAttribute returns a pointer to the specified attributes value as string or nil
 func (ac *AttributeContainer) GetStringAttribute(key string) *string {
-	attr := ac.Attributes[key]
-	return attr.GetStringValue()
+	return ac.attribute(key).GetStringValue()
 }
 
 // GetTagAttribute returns the specified attributes value as string slice or nil
 func (ac *AttributeContainer) GetTagAttribute(key string) []string {
-	attr := ac.Attributes[key]
-	return attr.GetTagValues()
+	return ac.attribute(key).GetTagValues()
 }
 
 // GetTimeAttribute returns a pointer to the specified attributes value as time.Time or nil
+//
+// See WithDateOnlyNormalization for normalizing dates of fetched containers
 func (ac *AttributeContainer) GetTimeAttribute(key string) *time.Time {
-	attr := ac.Attributes[key]
-	return attr.GetTimeValue()
+	value := ac.attribute(key).GetTimeValue()
+	if value == nil || ac.config == nil || ac.config.dateLocation == nil {
+		return value
+	}
+
+	// timestamps such as last_modified_at share the date type, only dates are sent as midnight in their offset
+	if value.Hour() != 0 || value.Minute() != 0 || value.Second() != 0 || value.Nanosecond() != 0 {
+		return value
+	}
+
+	// the calendar day as sent by Personio, regardless of the offset
+	year, month, day := value.Date()
+	normalized := time.Date(year, month, day, 0, 0, 0, 0, ac.config.dateLocation)
+	return &normalized
 }
 
 // GetMapAttribute returns a map of the nested value's attributes or an empty map
 func (ac *AttributeContainer) GetMapAttribute(key string) map[string]interface{} {
-	attr := ac.Attributes[key]
-	return attr.GetMapValue()
+	return ac.attribute(key).GetMapValue()
+}
+
+// GetListOfMapsAttribute returns the attributes of each object nested in the specified array value or an empty slice
+func (ac *AttributeContainer) GetListOfMapsAttribute(key string) []map[string]interface{} {
+	return ac.attribute(key).GetListOfMapsValue()
+}
+
+// RawValue returns the specified attribute's value as decoded from JSON regardless of its type, e.g. for attribute
+// types the typed getters don't know yet, and whether the attribute exists
+func (ac *AttributeContainer) RawValue(key string) (interface{}, bool) {
+	attr := ac.attribute(key)
+	if attr == nil {
+		return nil, false
+	}
+	return attr.Value, true
 }
 
 // Employee is a single employee entry
@@ -207,6 +611,30 @@ type Employee struct {
 	AttributeContainer
 }
 
+// UnmarshalJSON decodes the employee, needed as the embedded AttributeContainer's UnmarshalJSON would skip Type
+func (e *Employee) UnmarshalJSON(data []byte) error {
+
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil
+	}
+
+	var typed struct {
+		Type string `json:"type"`
+	}
+	err := json.Unmarshal(data, &typed)
+	if err != nil {
+		return err
+	}
+
+	err = e.AttributeContainer.UnmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+
+	e.Type = typed.Type
+	return nil
+}
+
 // TimeOff is a single time-off entry
 type TimeOff struct {
 	Id           int64        `json:"id"`
@@ -217,7 +645,10 @@ type TimeOff struct {
 	DaysCount    float64      `json:"days_count"`
 	HalfDayStart PersonioBool `json:"half_day_start"`
 	HalfDayEnd   PersonioBool `json:"half_day_end"`
-	TimeOffType  struct {
+	// MeasurementUnit ("days" or "hours", empty for days) is the unit of EffectiveDuration, see Amount()
+	MeasurementUnit   string  `json:"measurement_unit"`
+	EffectiveDuration float64 `json:"effective_duration"`
+	TimeOffType       struct {
 		Type       string `json:"type"`
 		Attributes struct {
 			Id       int64  `json:"id"`
@@ -232,6 +663,8 @@ type TimeOff struct {
 	} `json:"certificate"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// TypeName is the name of the time-off type as configured in Personio, filled by EnrichTimeOffTypeNames
+	TypeName string `json:"-"`
 }
 
 // EmployeeResult is the response body of /company/employee/{{id}}
@@ -245,158 +678,626 @@ type timeOffContainer struct {
 	Attributes TimeOff `json:"attributes"`
 }
 
-// pageResult is the response body of pageable endpoints
+// PageMetadata is the paging information Personio returns with a page of results
+type PageMetadata struct {
+	TotalElements int `json:"total_elements"`
+	CurrentPage   int `json:"current_page"`
+	TotalPages    int `json:"total_pages"`
+}
+
+// pageResult is the response body of pageable endpoints, Metadata is nil if not returned
 type pageResult struct {
-	Data []json.RawMessage `json:"data"`
+	Data     []json.RawMessage `json:"data"`
+	Metadata *PageMetadata     `json:"metadata"`
 }
 
 // Credentials is the secret to authenticate with the Personio API v1
+//
+// JSON keys are camelCase, YAML keys snake_case (e.g. client_id)
 type Credentials struct {
-	ClientId     string `json:"clientId"`
-	ClientSecret string `json:"clientSecret"`
-	AccessToken  string `json:"accessToken,omitempty"`
+	ClientId     string `json:"clientId" yaml:"client_id"`
+	ClientSecret string `json:"clientSecret" yaml:"client_secret"`
+	AccessToken  string `json:"accessToken,omitempty" yaml:"access_token,omitempty"`
+}
+
+// CredentialsFromMap reads Credentials from a generic configuration map using either camelCase or snake_case keys
+func CredentialsFromMap(m map[string]interface{}) (Credentials, error) {
+
+	var credentials Credentials
+	fields := []struct {
+		target *string
+		keys   []string
+	}{
+		{&credentials.ClientId, []string{"clientId", "client_id"}},
+		{&credentials.ClientSecret, []string{"clientSecret", "client_secret"}},
+		{&credentials.AccessToken, []string{"accessToken", "access_token"}},
+	}
+
+	for _, field := range fields {
+		for _, key := range field.keys {
+			value, ok := m[key]
+			if !ok {
+				continue
+			}
+			stringValue, ok := value.(string)
+			if !ok {
+				return Credentials{}, fmt.Errorf("credentials key %s is not a string", key)
+			}
+			*field.target = stringValue
+			break
+		}
+	}
+
+	if credentials.ClientId == "" || credentials.ClientSecret == "" {
+		return Credentials{}, errors.New("credentials require client id and client secret")
+	}
+
+	return credentials, nil
 }
 
 // Client is a Personio API v1 instance
 type Client struct {
 	ctx     context.Context
 	baseUrl string
-	client  http.Client
+	client  *http.Client
 	secret  Credentials
+	logger  Logger
+	redact  bool
+
+	// authContentType is the Content-Type of authentication requests
+	authContentType string
+
+	// authMethod and authPath select the endpoint of authentication requests
+	authMethod string
+	authPath   string
+
+	// rotationHeader is the response header carrying rotated access tokens
+	rotationHeader string
+
+	// validateToken rejects malformed static access tokens at construction
+	validateToken bool
+
+	// numericBools encodes booleans of request bodies as 0/1
+	numericBools bool
+
+	// activeOnly filters employee listings to active employees by default
+	activeOnly bool
+
+	// maxResults caps the number of time-offs accumulated across pages (0 for no cap)
+	maxResults int
+
+	// location calendar days are evaluated in (nil for the location of the passed times) and clock provides the current time
+	location *time.Location
+	clock    func() time.Time
+
+	// debugWriter receives dumps of requests and responses
+	debugWriter io.Writer
+
+	// lastRaw is the last response body received (at most lastRawLimit bytes, 0 disables recording)
+	lastRawMutex sync.Mutex
+	lastRaw      []byte
+	lastRawLimit int
+
+	// tlsConfig is applied to the default transport, noRedirects disables following redirects
+	tlsConfig   *tls.Config
+	noRedirects bool
+
+	// maxIdleConnsPerHost and keepAlive configure connection reuse of the default transport
+	maxIdleConnsPerHost int
+	keepAlive           time.Duration
+
+	// maxRetries, maxRetryWait and retryBackoff configure retries of temporarily failing requests
+	maxRetries   int
+	maxRetryWait time.Duration
+	retryBackoff time.Duration
+
+	// totalTimeout bounds each operation including all retries
+	totalTimeout time.Duration
+
+	// breaker short-circuits requests during outages
+	breaker *circuitBreaker
+
+	// spareTokens are idle access tokens besides secret.AccessToken, available to concurrent requests
+	// rotationWarned is set once a missing token rotation has been logged
+	// tokenExpiry is the expiry of fetched tokens (and their rotated successors), tokenTTL the fallback lifetime
+	tokenMutex     sync.Mutex
+	spareTokens    []string
+	rotationWarned bool
+	tokenExpiry    map[string]time.Time
+	tokenTTL       time.Duration
+
+	// timeOffTypes caches the time-off types fetched at timeOffTypesFetched
+	timeOffTypesMutex   sync.Mutex
+	timeOffTypes        []TimeOffType
+	timeOffTypesFetched time.Time
+	timeOffTypesTTL     time.Duration
+
+	// daysCountCheck is called for fetched time-offs whose DaysCount deviates from their working days by more than daysCountThreshold
+	daysCountCheck     DaysCountCheck
+	daysCountThreshold float64
+
+	// stats counts requests for Stats
+	stats *clientStats
+
+	// attributeConfig is attached to fetched employees, normalizeDates enables its dateLocation once options are applied
+	attributeConfig attributeConfig
+	normalizeDates  bool
+
+	// timeOffTypeCheck enables checking new time-offs against their time-off type's capabilities
+	timeOffTypeCheck bool
+
+	// employeeRefs caches the employees fetched by ResolveEmployeeRefAttribute by ID
+	employeeRefsMutex sync.Mutex
+	employeeRefs      map[int64]*Employee
 }
 
-// NewClientWithTimeout creates a new Client instance with the specified credentials and timeout
-func NewClientWithTimeout(ctx context.Context, baseUrl string, secret Credentials, timeout time.Duration) (*Client, error) {
+// NewClientWithTimeout creates a new Client instance with the specified credentials, timeout and options
+func NewClientWithTimeout(ctx context.Context, baseUrl string, secret Credentials, timeout time.Duration, opts ...Option) (*Client, error) {
 
 	if baseUrl == "" {
 		baseUrl = DefaultBaseUrl
 	}
 
-	return &Client{
+	personio := &Client{
 		ctx:     ctx,
 		baseUrl: baseUrl,
-		client:  http.Client{Timeout: timeout},
 		secret:  secret,
-	}, nil
-}
 
-// NewClient creates a new Client instance with the specified Credentials
-func NewClient(ctx context.Context, baseUrl string, secret Credentials) (*Client, error) {
-	return NewClientWithTimeout(ctx, baseUrl, secret, time.Duration(40)*time.Second)
+		authContentType: DefaultAuthContentType,
+		authMethod:      DefaultAuthMethod,
+		authPath:        DefaultAuthPath,
+		rotationHeader:  DefaultRotationHeader,
+		clock:           time.Now,
+		tokenTTL:        DefaultTokenTTL,
+		timeOffTypesTTL: DefaultTimeOffTypesTTL,
+		maxRetryWait:    DefaultMaxRetryWait,
+		retryBackoff:    defaultRetryBackoff,
+		stats:           &clientStats{},
+	}
+
+	for _, opt := range opts {
+		opt(personio)
+	}
+
+	if personio.normalizeDates {
+		personio.attributeConfig.dateLocation = personio.location
+		if personio.attributeConfig.dateLocation == nil {
+			personio.attributeConfig.dateLocation = time.UTC
+		}
+	}
+
+	if personio.validateToken && secret.AccessToken != "" {
+		if err := validateAccessToken(secret.AccessToken); err != nil {
+			return nil, err
+		}
+	}
+
+	if personio.client == nil {
+		personio.client = personio.newDefaultHttpClient(timeout)
+	}
+
+	return personio, nil
 }
 
-// doRequest processes the specified request, optionally handling authentication
-func (personio *Client) doRequest(request *http.Request, useAuthentication bool) ([]byte, error) {
+// NewClient creates a new Client instance with the specified Credentials and options
+func NewClient(ctx context.Context, baseUrl string, secret Credentials, opts ...Option) (*Client, error) {
+	return NewClientWithTimeout(ctx, baseUrl, secret, time.Duration(40)*time.Second, opts...)
+}
 
-	// authenticate
-	if useAuthentication && personio.secret.AccessToken == "" {
-		token, err := personio.Authenticate(personio.secret.ClientId, personio.secret.ClientSecret)
+// rewindRequest returns a copy of the specified request with a fresh body so it can be sent again
+func rewindRequest(request *http.Request) (*http.Request, error) {
+	rewound := request.Clone(request.Context())
+	if request.Body != nil && request.Body != http.NoBody {
+		if request.GetBody == nil {
+			return nil, errors.New("request body can not be rewound")
+		}
+		body, err := request.GetBody()
 		if err != nil {
 			return nil, err
 		}
+		rewound.Body = body
+	}
+	return rewound, nil
+}
 
-		personio.secret.AccessToken = token
+// ErrTokenRotationLoop is wrapped by the StatusError of requests aborted because Personio kept rejecting its own rotated tokens
+var ErrTokenRotationLoop = errors.New("personio access token rotation loop")
+
+// maxRejectedRotations is the number of rotated tokens rejected with 401 within a single request before giving up
+const maxRejectedRotations = 2
+
+// doRequest processes the specified request, optionally handling authentication
+//
+// Authenticated requests rejected with 401 or an auth error in the response body (see IsAuthError) are retried once
+// with a freshly fetched access token, requests rejected with 429 or 503 are retried as configured via WithRetries. Requests are aborted with
+// ErrTokenRotationLoop if tokens rotated during the request are repeatedly rejected on their next use.
+func (personio *Client) doRequest(request *http.Request, useAuthentication bool) ([]byte, http.Header, error) {
+
+	// requests carrying a cancellable context (e.g. of a paged operation) keep it, others are tied to the client's
+	ctx, cancel := personio.withTotalTimeout(request.Context())
+	defer cancel()
+	request = request.WithContext(ctx)
+
+	if request.Header.Get(RequestIdHeader) == "" {
+		request.Header.Set(RequestIdHeader, newRequestId(ctx))
 	}
 
-	if useAuthentication && personio.secret.AccessToken != "" {
-		(*request).Header.Set("Authorization", "Bearer "+personio.secret.AccessToken)
-		personio.secret.AccessToken = "" // token consumed
+	reauthenticated := false
+	needFreshToken := false
+	retries := 0
+	rotatedTokens := map[string]bool{}
+	rejectedRotations := 0
+	for {
+		// authenticate (failures are not retried as they already went through this loop)
+		token := ""
+		if useAuthentication {
+			// after a 401 pooled tokens are as stale as the rejected one
+			if !needFreshToken {
+				token = personio.takeToken()
+			}
+			if token == "" {
+				var err error
+				var expiry time.Time
+				token, expiry, err = personio.authenticate(ctx, personio.secret.ClientId, personio.secret.ClientSecret)
+				if err != nil {
+					return nil, nil, err
+				}
+				personio.setTokenExpiry(token, expiry)
+				needFreshToken = false
+			}
+		}
+
+		// prepare a retry before the body is consumed
+		retryRequest, _ := rewindRequest(request)
+
+		body, header, err := personio.doRequestOnce(request, useAuthentication, token)
+		if err == nil && useAuthentication {
+			err = personio.envelopeAuthError(body, request)
+		}
+
+		var statusErr StatusError
+		if retryRequest == nil || !errors.As(err, &statusErr) {
+			return body, header, err
+		}
+
+		// guard against a server rejecting the very tokens it rotated to
+		if statusErr.Code == http.StatusUnauthorized && useAuthentication && token != "" && rotatedTokens[token] {
+			rejectedRotations++
+			if rejectedRotations >= maxRejectedRotations {
+				statusErr.Err = fmt.Errorf("%w: %s", ErrTokenRotationLoop, statusErr.Err)
+				return nil, header, statusErr
+			}
+		}
+		if next := strings.Replace(header.Get(personio.rotationHeader), "Bearer ", "", 1); next != "" {
+			rotatedTokens[next] = true
+		}
+
+		if statusErr.Code == http.StatusUnauthorized && useAuthentication && !reauthenticated {
+			// the token might have been invalidated early, re-authenticate once
+			reauthenticated = true
+			needFreshToken = true
+		} else if isRetryable(statusErr.Code) && retries < personio.maxRetries {
+			wait, err := personio.retryWait(statusErr, header, retries)
+			if err != nil {
+				return nil, header, err
+			}
+			err = sleep(ctx, wait)
+			if err != nil {
+				return nil, header, err
+			}
+			retries++
+			atomic.AddInt64(&personio.stats.retries, 1)
+		} else {
+			return body, header, err
+		}
+
+		request = retryRequest
 	}
+}
 
-	var response *http.Response
-	var err error
-	if personio.ctx == nil {
-		response, err = personio.client.Do(request)
+// doRequestOnce processes the specified request exactly once unless the circuit breaker is open
+//
+// An unused token is returned to the idle tokens if the circuit breaker is open
+func (personio *Client) doRequestOnce(request *http.Request, useAuthentication bool, token string) ([]byte, http.Header, error) {
+
+	if personio.breaker == nil {
+		return personio.sendRequest(request, useAuthentication, token)
+	}
+
+	err := personio.breaker.allow(personio.clock())
+	if err != nil {
+		personio.putToken(token)
+		return nil, nil, err
+	}
+
+	body, header, err := personio.sendRequest(request, useAuthentication, token)
+	personio.breaker.record(personio.clock(), err)
+
+	return body, header, err
+}
+
+// takeToken removes and returns an idle access token or "" if there is none
+//
+// Tokens known to have expired are dropped
+func (personio *Client) takeToken() string {
+	personio.tokenMutex.Lock()
+	defer personio.tokenMutex.Unlock()
+
+	for {
+		token := personio.secret.AccessToken
+		if token != "" {
+			personio.secret.AccessToken = ""
+		} else if len(personio.spareTokens) > 0 {
+			token = personio.spareTokens[len(personio.spareTokens)-1]
+			personio.spareTokens = personio.spareTokens[:len(personio.spareTokens)-1]
+		}
+
+		expiry, ok := personio.tokenExpiry[token]
+		if token == "" || !ok || personio.clock().Before(expiry) {
+			return token
+		}
+		delete(personio.tokenExpiry, token)
+	}
+}
+
+// setTokenExpiry records the expiry of a freshly fetched access token
+func (personio *Client) setTokenExpiry(token string, expiry time.Time) {
+	personio.tokenMutex.Lock()
+	defer personio.tokenMutex.Unlock()
+
+	if personio.tokenExpiry == nil {
+		personio.tokenExpiry = map[string]time.Time{}
+	}
+	personio.tokenExpiry[token] = expiry
+}
+
+// rotateTokenExpiry moves the expiry of the consumed token to its rotated successor next ("" if none)
+//
+// Personio might extend the lifetime on rotation, but keeping the original expiry is the conservative choice
+func (personio *Client) rotateTokenExpiry(token string, next string) {
+	personio.tokenMutex.Lock()
+	defer personio.tokenMutex.Unlock()
+
+	expiry, ok := personio.tokenExpiry[token]
+	if !ok {
+		return
+	}
+	delete(personio.tokenExpiry, token)
+	if next != "" {
+		personio.tokenExpiry[next] = expiry
+	}
+}
+
+// putToken adds an access token to the idle tokens
+func (personio *Client) putToken(token string) {
+	if token == "" {
+		return
+	}
+
+	personio.tokenMutex.Lock()
+	defer personio.tokenMutex.Unlock()
+
+	if personio.secret.AccessToken == "" {
+		personio.secret.AccessToken = token
 	} else {
-		response, err = personio.client.Do(request.WithContext(personio.ctx))
+		personio.spareTokens = append(personio.spareTokens, token)
+	}
+}
+
+// sendRequest sends the specified request, optionally attaching the access token token and keeping its rotated successor
+//
+// Access tokens are single-use, so concurrent requests each need their own token
+func (personio *Client) sendRequest(request *http.Request, useAuthentication bool, token string) ([]byte, http.Header, error) {
+
+	if useAuthentication && token != "" {
+		(*request).Header.Set("Authorization", "Bearer "+token)
+	}
+
+	personio.dumpRequest(request)
+
+	atomic.AddInt64(&personio.stats.requests, 1)
+	response, err := personio.client.Do(request)
+	if err != nil {
 		// preserve error of cancelled context
-		if err != nil {
-			select {
-			case <-personio.ctx.Done():
-				err = personio.ctx.Err()
-			default:
-			}
+		select {
+		case <-request.Context().Done():
+			err = request.Context().Err()
+		default:
 		}
 	}
 	if err != nil {
-		return nil, err
+		// the token is only consumed by requests reaching Personio
+		personio.putToken(token)
+		return nil, nil, err
 	}
 
 	defer func(Body io.ReadCloser) {
 		_ = Body.Close()
 	}(response.Body)
 
+	if response.StatusCode == http.StatusUnauthorized {
+		atomic.AddInt64(&personio.stats.unauthorized, 1)
+	}
+
 	if useAuthentication {
 		// cycle or reset accessToken
-		nextAuthorization := strings.Replace(response.Header.Get("authorization"), "Bearer ", "", 1)
+		nextAuthorization := strings.Replace(response.Header.Get(personio.rotationHeader), "Bearer ", "", 1)
+		personio.rotateTokenExpiry(token, nextAuthorization)
 		if nextAuthorization != "" {
-			personio.secret.AccessToken = nextAuthorization
+			personio.putToken(nextAuthorization)
+			personio.tokenMutex.Lock()
+			personio.rotationWarned = false
+			personio.tokenMutex.Unlock()
+		} else if response.StatusCode >= http.StatusOK && response.StatusCode < http.StatusMultipleChoices {
+			personio.tokenMutex.Lock()
+			warn := !personio.rotationWarned
+			personio.rotationWarned = true
+			personio.tokenMutex.Unlock()
+			if warn {
+				// the consumed token was not replaced, so every following request needs to re-authenticate
+				personio.logf("personio: %s %s (request id %s) returned no rotated access token, re-authenticating on every request", request.Method, request.URL.Path, request.Header.Get(RequestIdHeader))
+			}
 		}
 	}
 
+	var body []byte
+	body, err = io.ReadAll(response.Body)
+
+	personio.dumpResponse(request, response, body)
+	personio.recordRawResponse(request, body)
+
 	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
-		return nil, StatusError{errors.New(response.Status), response.StatusCode}
+		return nil, response.Header, StatusError{errors.New(response.Status), response.StatusCode, request.Header.Get(RequestIdHeader)}
 	}
 
-	var body []byte
-	body, err = io.ReadAll(response.Body)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return body, nil
+	return body, response.Header, nil
 }
 
 // doRequestJson processes the specified request assuming JSON data is exchanged
 func (personio *Client) doRequestJson(request *http.Request, useAuthentication bool) ([]byte, error) {
+	body, _, err := personio.doRequestJsonWithHeader(request, useAuthentication)
+	return body, err
+}
+
+// doRequestJsonWithHeader processes the specified request assuming JSON data is exchanged and also returns the response headers
+func (personio *Client) doRequestJsonWithHeader(request *http.Request, useAuthentication bool) ([]byte, http.Header, error) {
 
 	request.Header.Set("Accept", "application/json")
 
-	body, err := personio.doRequest(request, useAuthentication)
+	body, header, err := personio.doRequest(request, useAuthentication)
 	if err != nil {
-		return nil, err
+		return nil, header, err
 	}
 
 	var result resultBody
 	err = json.Unmarshal(body, &result)
 	if err != nil {
-		return nil, err
+		return nil, header, err
 	}
 
 	if !result.Success {
-		return nil, fmt.Errorf("personio returned error: code=%d, message=%s", result.Error.Code, result.Error.Message)
+		return nil, header, personio.resultError(result, request)
+	}
+
+	return body, header, nil
+}
+
+// resultError returns the error reported by Personio in the result envelope of the specified request
+func (personio *Client) resultError(result resultBody, request *http.Request) error {
+	message := result.Error.Message
+	if personio.redact {
+		// error messages may contain attribute values such as names or emails
+		message = redactedMessage
+	}
+	return PersonioError{Code: result.Error.Code, Message: message, RequestId: request.Header.Get(RequestIdHeader)}
+}
+
+// envelopeAuthError returns a StatusError with status 401 if body is a JSON envelope reporting an auth error, else nil
+//
+// This lets doRequest re-authenticate on auth errors Personio reports with a successful HTTP status
+func (personio *Client) envelopeAuthError(body []byte, request *http.Request) error {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil
+	}
+
+	// successful responses are decoded again by the caller, so only the envelope is decoded here and data is skipped
+	var envelope struct {
+		Success bool `json:"success"`
+		Error   struct {
+			Code    int    `json:"code,omitempty"`
+			Message string `json:"message,omitempty"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(trimmed, &envelope) != nil || envelope.Success || !authErrorCodes[envelope.Error.Code] {
+		return nil
+	}
+
+	var result resultBody
+	result.Error = envelope.Error
+	return StatusError{personio.resultError(result, request), http.StatusUnauthorized, request.Header.Get(RequestIdHeader)}
+}
+
+// Do sends a raw request to the path relative to the base URL and returns the raw response body
+//
+// It is an escape hatch for endpoints not wrapped by Client, authentication is only handled if authenticate is true
+func (personio *Client) Do(method string, relpath string, body io.Reader, authenticate bool) ([]byte, error) {
+
+	req, err := http.NewRequest(method, personio.baseUrl+relpath, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
 
-	return body, nil
+	responseBody, _, err := personio.doRequest(req, authenticate)
+	return responseBody, err
 }
 
 // Authenticate fetches a new access token for the given clientId and clientSecret
 func (personio *Client) Authenticate(clientId string, clientSecret string) (string, error) {
+	token, _, err := personio.authenticate(context.Background(), clientId, clientSecret)
+	return token, err
+}
+
+// AuthenticateWithExpiry fetches a new access token like Authenticate together with its expiry
+//
+// The expiry is taken from the auth response if Personio reports it, else it is estimated using the TTL configured
+// via WithTokenTTL (see Auth.Expiry).
+func (personio *Client) AuthenticateWithExpiry(clientId string, clientSecret string) (string, time.Time, error) {
+	return personio.authenticate(context.Background(), clientId, clientSecret)
+}
+
+// authenticate fetches a new access token and its expiry within ctx, falling back to the client's context if ctx can't be cancelled
+func (personio *Client) authenticate(ctx context.Context, clientId string, clientSecret string) (string, time.Time, error) {
 
 	form := url.Values{}
 	form.Add("client_id", clientId)
 	form.Add("client_secret", clientSecret)
 
-	req, err := http.NewRequest(http.MethodPost, personio.baseUrl+"/auth", strings.NewReader(form.Encode()))
-	if err != nil {
-		return "", err
+	// only POST carries the credentials in a body, other methods pass them as query
+	var req *http.Request
+	var err error
+	if personio.authMethod == http.MethodPost {
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, personio.baseUrl+personio.authPath, strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		req.Header.Set("Content-Type", personio.authContentType)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, personio.authMethod, personio.baseUrl+personio.authPath+"?"+form.Encode(), nil)
+		if err != nil {
+			return "", time.Time{}, err
+		}
 	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	atomic.AddInt64(&personio.stats.authCalls, 1)
 
 	var body []byte
 	body, err = personio.doRequestJson(req, false)
 	if err != nil {
-		return "", err
+		// transport errors quote the request URL including the query
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			redacted := redactCredentials(*req.URL)
+			urlErr.URL = redacted.String()
+		}
+		return "", time.Time{}, err
 	}
 
 	var auth Auth
 	err = json.Unmarshal(body, &auth)
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 
-	return auth.Data.Token, nil
+	return auth.Data.Token, auth.Expiry(personio.clock(), personio.tokenTTL), nil
 }
 
 // GetEmployee fetches one or multiple employees.json by optional ID
@@ -419,73 +1320,158 @@ func (personio *Client) GetEmployee(id int64) (*Employee, error) {
 	}
 
 	// unpack single Employee element
+	personio.applyAttributeConfig(&employeeResult.Data)
 	return &employeeResult.Data, nil
 }
 
+// getPage fetches a single page of objects at offset (in elements or, for time-offs, in pages) with at most limit elements
+//
+// The request is bound to ctx, context.Background() makes it use the Client's context
+func (personio *Client) getPage(ctx context.Context, relpath string, query url.Values, offset int, limit int) (*pageResult, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, personio.baseUrl+relpath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	realQuery := req.URL.Query()
+	for k, v := range query {
+		realQuery[k] = v
+	}
+	realQuery.Add("limit", strconv.Itoa(limit))
+	realQuery.Add("offset", strconv.Itoa(offset))
+	req.URL.RawQuery = realQuery.Encode()
+
+	body, err := personio.doRequestJson(req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result pageResult
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
 // getPages fetches the pages of objects specified via offset and limit as individual json.RawMessage per object
+//
+// Paging stops at the first short page unless the returned metadata announces more elements, e.g. because Personio
+// caps the page size below the requested limit
 func (personio *Client) getPages(relpath string, query url.Values, offset int, limit int) ([]*pageResult, int, error) {
+	return personio.getPagesUpTo(context.Background(), relpath, query, offset, limit, limit)
+}
+
+// getPagesUpTo is getPages stopping after maxCount objects, page sizes (and thus page offsets) are still derived from limit
+//
+// Requests are bound to ctx like in getPage and share one total timeout, paging stops with ctx.Err() once ctx is done
+func (personio *Client) getPagesUpTo(ctx context.Context, relpath string, query url.Values, offset int, limit int, maxCount int) ([]*pageResult, int, error) {
+	if maxCount > limit {
+		maxCount = limit
+	}
+
+	ctx, cancel := personio.withTotalTimeout(ctx)
+	defer cancel()
+
 	var count = 0
 	var results []*pageResult
-	for count < limit {
+	for count < maxCount {
 
-		req, err := http.NewRequest(http.MethodGet, personio.baseUrl+relpath, nil)
-		if err != nil {
+		if err := ctx.Err(); err != nil {
 			return nil, 0, err
 		}
 
-		realQuery := req.URL.Query()
-		for k, v := range query {
-			realQuery[k] = v
-		}
-
 		pageLimit := limit
-		if pageLimit > pagingMaxLimit {
-			pageLimit = pagingMaxLimit
-		}
-
-		realQuery.Add("limit", strconv.Itoa(pageLimit))
-		if relpath == "/company/time-offs" {
-			// time-offs endpoint offset's unit is pages
-			realQuery.Add("offset", strconv.Itoa(offset+(count/pageLimit)))
-		} else {
-			realQuery.Add("offset", strconv.Itoa(offset+count))
+		if pageLimit > maxPageSize(relpath) {
+			pageLimit = maxPageSize(relpath)
 		}
-		req.URL.RawQuery = realQuery.Encode()
 
-		body, err := personio.doRequestJson(req, true)
-		if err != nil {
-			return nil, 0, err
+		// time-offs endpoint offset's unit is pages
+		pagedByElements := relpath != "/company/time-offs"
+		pageOffset := offset + count
+		if !pagedByElements {
+			pageOffset = offset + (count / pageLimit)
 		}
 
-		var result pageResult
-		err = json.Unmarshal(body, &result)
+		result, err := personio.getPage(ctx, relpath, query, pageOffset, pageLimit)
 		if err != nil {
 			return nil, 0, err
 		}
 
 		resultLength := len(result.Data)
 		if resultLength > 0 {
-			remainingLength := limit - count
+			remainingLength := maxCount - count
 			if remainingLength < resultLength {
 				// exactly return number of elements specified by limit
 				result.Data = result.Data[:remainingLength]
 			}
-			results = append(results, &result)
+			results = append(results, result)
 			count += resultLength
 		}
 
 		if resultLength < pageLimit {
-			break
+			announced := pagedByElements && resultLength > 0 && result.Metadata != nil && offset+count < result.Metadata.TotalElements
+			if !announced {
+				break
+			}
 		}
 	}
 
 	return results, count, nil
 }
 
-// GetEmployees returns all employees
+// GetEmployees returns all employees (only active ones if WithDefaultActiveOnly is set)
 func (personio *Client) GetEmployees() ([]*Employee, error) {
+	return personio.GetEmployeesWithParams(nil)
+}
+
+// GetEmployeesWithParams returns all employees matching the additional query parameters (e.g. filters not wrapped yet)
+//
+// Parameters limit and offset are controlled by the paging and ignored. Passing a status parameter overrides WithDefaultActiveOnly.
+// The slice is empty, never nil, if no employee matches.
+func (personio *Client) GetEmployeesWithParams(params url.Values) ([]*Employee, error) {
+	employees, err := personio.getEmployees(context.Background(), params)
+	if err != nil {
+		return nil, err
+	}
+	return personio.filterDefaultActive(employees, params), nil
+}
 
-	results, count, err := personio.getPages("/company/employees", url.Values{}, 0, intMax)
+// GetEmployeesContext returns all employees like GetEmployees with all requests bound to ctx
+//
+// Cancelling ctx stops fetching further pages and returns ctx.Err(), e.g. to abort long-running exports
+func (personio *Client) GetEmployeesContext(ctx context.Context) ([]*Employee, error) {
+	employees, err := personio.getEmployees(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return personio.filterDefaultActive(employees, nil), nil
+}
+
+// filterDefaultActive returns the active employees if WithDefaultActiveOnly is set and params has no status parameter
+func (personio *Client) filterDefaultActive(employees []*Employee, params url.Values) []*Employee {
+	if personio.activeOnly && !params.Has("status") {
+		return activeEmployees(employees)
+	}
+	return employees
+}
+
+// getEmployees returns all employees matching params like GetEmployeesWithParams, the requests bound to ctx like in getPage
+//
+// Unlike the public getters it ignores WithDefaultActiveOnly, e.g. so syncs notice employees becoming inactive
+func (personio *Client) getEmployees(ctx context.Context, params url.Values) ([]*Employee, error) {
+
+	query := url.Values{}
+	for key, values := range params {
+		if key == "limit" || key == "offset" {
+			continue
+		}
+		query[key] = append([]string(nil), values...)
+	}
+
+	results, count, err := personio.getPagesUpTo(ctx, "/company/employees", query, 0, intMax, intMax)
 	if err != nil {
 		return nil, err
 	}
@@ -500,6 +1486,7 @@ func (personio *Client) GetEmployees() ([]*Employee, error) {
 			if err != nil {
 				return nil, err
 			}
+			personio.applyAttributeConfig(&result)
 			employees[idx] = &result
 			idx++
 		}
@@ -508,23 +1495,54 @@ func (personio *Client) GetEmployees() ([]*Employee, error) {
 	return employees, nil
 }
 
-// GetTimeOffs returns the time-offs matching the specified start and end dates (inclusive, ignored if zero)
-//
-// Parameters offset and limit are not bound by the Personio APIs limits
-func (personio *Client) GetTimeOffs(start *time.Time, end *time.Time, offset int, limit int) ([]*TimeOff, error) {
-
+// timeOffsQuery returns the query selecting the time-offs matching the specified start and end dates (ignored if nil)
+func timeOffsQuery(start *time.Time, end *time.Time) url.Values {
 	query := url.Values{}
 	if start != nil {
-		query.Add("start_date", start.Format(queryDateFormat))
+		query.Add("start_date", util.FormatPersonioDate(*start, nil))
 	}
 	if end != nil {
-		query.Add("end_date", end.Format(queryDateFormat))
+		query.Add("end_date", util.FormatPersonioDate(*end, nil))
 	}
-	results, count, err := personio.getPages("/company/time-offs", query, offset, limit)
+	return query
+}
+
+// GetTimeOffs returns the time-offs matching the specified start and end dates (inclusive, ignored if zero)
+//
+// Dates are compared as calendar days, start and end in their own locations.
+// Parameters offset and limit are not bound by the Personio APIs limits. Results exceeding the cap set via
+// WithMaxResults are dropped and reported to the Logger, use GetTimeOffsCapped to detect this.
+// The slice is empty, never nil, if no time-off matches. Personio always embeds the full employee object, it can't be omitted.
+func (personio *Client) GetTimeOffs(start *time.Time, end *time.Time, offset int, limit int) ([]*TimeOff, error) {
+
+	timeOffs, truncated, err := personio.GetTimeOffsCapped(start, end, offset, limit)
 	if err != nil {
 		return nil, err
 	}
 
+	if truncated {
+		personio.logf("personio: time-offs truncated to %d results as configured via WithMaxResults", personio.maxResults)
+	}
+
+	return timeOffs, nil
+}
+
+// GetTimeOffsCapped returns the time-offs like GetTimeOffs and whether they were truncated to the cap set via WithMaxResults
+func (personio *Client) GetTimeOffsCapped(start *time.Time, end *time.Time, offset int, limit int) ([]*TimeOff, bool, error) {
+
+	query := timeOffsQuery(start, end)
+
+	// fetch one more than the cap to tell whether results were dropped
+	maxCount := limit
+	if personio.maxResults > 0 && personio.maxResults < limit {
+		maxCount = personio.maxResults + 1
+	}
+
+	results, count, err := personio.getPagesUpTo(context.Background(), "/company/time-offs", query, offset, limit, maxCount)
+	if err != nil {
+		return nil, false, err
+	}
+
 	// unpack TimeOff elements
 	timeOffs := make([]*TimeOff, count)
 	idx := 0
@@ -533,17 +1551,26 @@ func (personio *Client) GetTimeOffs(start *time.Time, end *time.Time, offset int
 			var result timeOffContainer
 			err = json.Unmarshal(results[i].Data[j], &result)
 			if err != nil {
-				return nil, err
+				return nil, false, err
 			}
+			personio.applyAttributeConfig(&result.Attributes.Employee)
 			timeOffs[idx] = &result.Attributes
 			idx++
 		}
 	}
 
-	return timeOffs, nil
+	truncated := personio.maxResults > 0 && len(timeOffs) > personio.maxResults
+	if truncated {
+		timeOffs = timeOffs[:personio.maxResults]
+	}
+	personio.checkDaysCount(timeOffs)
+
+	return timeOffs, truncated, nil
 }
 
 // GetTimeOffsMapped returns a slice of timeOffs with times mapped from HalfDayStart/HalfDayEnd/DaysCount
+//
+// The slice is empty, never nil, if no time-off matches
 func (personio *Client) GetTimeOffsMapped(start time.Time, end time.Time) ([]*TimeOff, error) {
 
 	timeOffs, err := personio.GetTimeOffs(&start, &end, 0, 2147483647)
@@ -551,7 +1578,7 @@ func (personio *Client) GetTimeOffsMapped(start time.Time, end time.Time) ([]*Ti
 		return nil, err
 	}
 
-	var matchedTimeOffs []*TimeOff
+	matchedTimeOffs := make([]*TimeOff, 0, len(timeOffs))
 	for _, timeOff := range timeOffs {
 
 		// we need to adjust the wall-clock time according to other fields
@@ -586,3 +1613,111 @@ func (personio *Client) GetTimeOffsMapped(start time.Time, end time.Time) ([]*Ti
 
 	return matchedTimeOffs, nil
 }
+
+// CreateTimeOffRequest is the specification of a time-off to be created
+type CreateTimeOffRequest struct {
+	EmployeeId    int64
+	TimeOffTypeId int64
+	StartDate     time.Time
+	EndDate       time.Time
+	HalfDayStart  bool
+	HalfDayEnd    bool
+	Comment       string
+	SkipApproval  bool
+}
+
+// createTimeOffBody is the request body of POST /company/time-offs
+type createTimeOffBody struct {
+	EmployeeId    int64       `json:"employee_id"`
+	TimeOffTypeId int64       `json:"time_off_type_id"`
+	StartDate     string      `json:"start_date"`
+	EndDate       string      `json:"end_date"`
+	HalfDayStart  interface{} `json:"half_day_start"`
+	HalfDayEnd    interface{} `json:"half_day_end"`
+	Comment       string      `json:"comment,omitempty"`
+	SkipApproval  bool        `json:"skip_approval,omitempty"`
+}
+
+// encodeBool returns the boolean as PersonioBool or as NumericBool if configured via WithNumericBools
+func (personio *Client) encodeBool(b bool) interface{} {
+	if personio.numericBools {
+		return NumericBool(b)
+	}
+	return PersonioBool(b)
+}
+
+// timeOffResult is the response body of endpoints returning a single time-off
+type timeOffResult struct {
+	Data timeOffContainer `json:"data"`
+}
+
+// resolveLocation returns the absolute URL of a created resource from the Location header or the fallback path
+func (personio *Client) resolveLocation(request *http.Request, header http.Header, fallbackPath string) string {
+	if location := header.Get("Location"); location != "" {
+		resolved, err := request.URL.Parse(location)
+		if err == nil {
+			return resolved.String()
+		}
+	}
+	return personio.baseUrl + fallbackPath
+}
+
+// CreateTimeOff creates a new time-off and returns it together with its location
+//
+// The location is taken from the Location header of the response or derived from the ID of the created time-off.
+// See WithTimeOffTypeCheck for checking the time-off type before sending the request.
+func (personio *Client) CreateTimeOff(timeOff CreateTimeOffRequest) (*TimeOff, string, error) {
+	return personio.createTimeOff(context.Background(), timeOff)
+}
+
+// createTimeOff is CreateTimeOff with all requests bound to ctx like in getPage
+func (personio *Client) createTimeOff(ctx context.Context, timeOff CreateTimeOffRequest) (*TimeOff, string, error) {
+
+	ctx, cancel := personio.withTotalTimeout(ctx)
+	defer cancel()
+
+	if personio.timeOffTypeCheck {
+		err := personio.checkTimeOffType(ctx, timeOff)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	requestBody, err := json.Marshal(createTimeOffBody{
+		EmployeeId:    timeOff.EmployeeId,
+		TimeOffTypeId: timeOff.TimeOffTypeId,
+		StartDate:     util.FormatPersonioDate(timeOff.StartDate, nil),
+		EndDate:       util.FormatPersonioDate(timeOff.EndDate, nil),
+		HalfDayStart:  personio.encodeBool(timeOff.HalfDayStart),
+		HalfDayEnd:    personio.encodeBool(timeOff.HalfDayEnd),
+		Comment:       timeOff.Comment,
+		SkipApproval:  timeOff.SkipApproval,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, personio.baseUrl+"/company/time-offs", bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	body, header, err := personio.doRequestJsonWithHeader(req, true)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var result timeOffResult
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		return nil, "", err
+	}
+
+	created := &result.Data.Attributes
+	personio.applyAttributeConfig(&created.Employee)
+	location := personio.resolveLocation(req, header, fmt.Sprintf("/company/time-offs/%d", created.Id))
+
+	return created, location, nil
+}
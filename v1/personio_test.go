@@ -3,14 +3,19 @@ package v1
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -18,24 +23,243 @@ import (
 )
 
 // lastToken is the last token HandlePersonioMock() successfully authenticated
+// disableRotation simulates Personio no longer sending rotated tokens
+// omitLocation omits the Location header of created resources
+// rejectTokens rejects all access tokens and authCount counts successful /auth requests
+// timeOffTypesCount counts requests of time-off types and lastRequestId is the last received correlation ID
+// employeePatches are the attribute values patched per employee ID, applied when serving employees
+// unavailable are the Retry-After headers of the next requests rejected with 503 Service Unavailable
+// timeOffStatuses override the status of time-offs served individually, changed by PATCH requests
+// lastAuthContentType is the Content-Type of the last /auth request
+// lastTimeOffBody is the raw body of the last request creating a time-off
+// lastLimit is the page size requested by the last fixture page request
+// maxPageSize caps the number of elements per fixture page below the requested limit (if set)
+// uniqueTokens issues distinct single-use tokens (tracked in validTokens) instead of alternating "ghi" and "jkl"
+// lastAttendancesBody is the raw body of the last request creating or patching attendances
+// deletedAttendances are the fixture attendances deleted by DELETE requests
+// forbiddenEmployees are the employees whose absence balance is denied with 403 Forbidden
+// authExpiresIn is the token lifetime in seconds reported by /auth (omitted if 0)
+// envelopeAuthErrors is the number of next authenticated requests answered with an auth error in a 200 response body
+// extraEmployees are the IDs of single employee fixtures (employee-{id}.json) appended to the employees listing
 type PersonioMock struct {
-	lastToken string
+	mutex               sync.Mutex
+	lastToken           string
+	lastRequestId       string
+	disableRotation     bool
+	omitLocation        bool
+	rejectTokens        bool
+	authCount           int
+	timeOffTypesCount   int
+	employeePatches     map[int64][]map[string]interface{}
+	unavailable         []string
+	uniqueTokens        bool
+	lastAuthContentType string
+	validTokens         map[string]bool
+	tokenCount          int
+	timeOffStatuses     map[int64]string
+	maxPageSize         int
+	lastLimit           int
+	lastTimeOffBody     []byte
+	envelopeAuthErrors  int
+	authExpiresIn       int
+	forbiddenEmployees  map[int64]bool
+	lastAttendancesBody []byte
+	deletedAttendances  map[int64]bool
+	extraEmployees      []int64
+}
+
+// findTimeOff returns the fixture time-off with the specified ID (status overridden by timeOffStatuses) or nil
+func (p *PersonioMock) findTimeOff(id int64) (*timeOffContainer, error) {
+
+	timeOffsData, err := os.ReadFile(filepath.Join("testdata", "time-offs-body.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data []timeOffContainer `json:"data"`
+	}
+	err = json.Unmarshal(timeOffsData, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range result.Data {
+		if result.Data[i].Attributes.Id == id {
+			if status, ok := p.timeOffStatuses[id]; ok {
+				result.Data[i].Attributes.Status = status
+			}
+			return &result.Data[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// issueToken issues a new unique access token
+func (p *PersonioMock) issueToken() string {
+	if p.validTokens == nil {
+		p.validTokens = map[string]bool{}
+	}
+	p.tokenCount++
+	token := fmt.Sprintf("token-%d", p.tokenCount)
+	p.validTokens[token] = true
+	return token
+}
+
+// patchEmployee applies the recorded patches of the employee to its test data
+func (p *PersonioMock) patchEmployee(id int64, employeeData []byte) ([]byte, error) {
+	if len(p.employeePatches[id]) == 0 {
+		return employeeData, nil
+	}
+
+	var employee struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Type       string                            `json:"type"`
+			Attributes map[string]map[string]interface{} `json:"attributes"`
+		} `json:"data"`
+	}
+	err := json.Unmarshal(employeeData, &employee)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, patch := range p.employeePatches[id] {
+		for key, value := range patch {
+			if key == "custom_attributes" {
+				for customKey, customValue := range value.(map[string]interface{}) {
+					employee.Data.Attributes[customKey]["value"] = customValue
+				}
+			} else {
+				employee.Data.Attributes[key]["value"] = value
+			}
+		}
+	}
+
+	return json.Marshal(employee)
+}
+
+// overlapsQueryDates returns whether any day of the range from startDate to endDate lies within the queried days
+//
+// This mirrors the documented Personio semantics of start_date and end_date: both are inclusive Personio dates
+// (YYYY-MM-DD), an empty one is unbounded and entries are matched by their calendar days in their own time zone.
+func overlapsQueryDates(startDate time.Time, endDate time.Time, startArg string, endArg string) bool {
+	if startArg != "" && util.FormatPersonioDate(endDate, nil) < startArg {
+		return false
+	}
+	if endArg != "" && util.FormatPersonioDate(startDate, nil) > endArg {
+		return false
+	}
+	return true
+}
+
+// writeFixturePage writes the page of the fixture's data elements matching filter (all if nil) selected by the limit and offset query parameters
+//
+// Pages are capped at maxPageSize elements if set, the paging metadata refers to the filtered elements
+func (p *PersonioMock) writeFixturePage(w http.ResponseWriter, req *http.Request, fixture string, filter func(element json.RawMessage) bool) {
+
+	fixtureData, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		fmt.Printf("Failed to read %s test data file: %s\n", fixture, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var result pageResult
+	err = json.Unmarshal(fixtureData, &result)
+	if err != nil {
+		fmt.Printf("Failed to unmarshall %s test data file: %s\n", fixture, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if fixture == "employees.json" {
+		for _, id := range p.extraEmployees {
+			var employeeResult struct {
+				Data json.RawMessage `json:"data"`
+			}
+			employeeData, err := os.ReadFile(filepath.Join("testdata", fmt.Sprintf("employee-%d.json", id)))
+			if err == nil {
+				err = json.Unmarshal(employeeData, &employeeResult)
+			}
+			if err != nil {
+				fmt.Printf("Failed to read employee %d test data file: %s\n", id, err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			result.Data = append(result.Data, employeeResult.Data)
+		}
+	}
+
+	query := req.URL.Query()
+	limit, limitErr := strconv.Atoi(query.Get("limit"))
+	offset, offsetErr := strconv.Atoi(query.Get("offset"))
+	if limitErr != nil || limit < 1 || limit > maxPageSize(req.URL.Path) || offsetErr != nil || offset < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	p.lastLimit = limit
+	if p.maxPageSize > 0 && limit > p.maxPageSize {
+		limit = p.maxPageSize
+	}
+
+	filteredData := make([]json.RawMessage, 0)
+	count := 0
+	for i := range result.Data {
+		if filter != nil && !filter(result.Data[i]) {
+			continue
+		}
+		if count >= offset && count < offset+limit {
+			filteredData = append(filteredData, result.Data[i])
+		}
+		count++
+	}
+
+	metadata := PageMetadata{TotalElements: count, CurrentPage: offset / limit, TotalPages: (count + limit - 1) / limit}
+	responseBody, err := json.Marshal(map[string]interface{}{"success": true, "metadata": metadata, "data": filteredData})
+	if err != nil {
+		fmt.Printf("Failed to marshall filtered %s test data: %s\n", fixture, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(responseBody)
 }
 
 // authenticate Authenticates a request (valid access tokens: "ghi" and "jkl") and simulates token rotation
 func (p *PersonioMock) authenticate(w http.ResponseWriter, req *http.Request) bool {
 	// "authenticate"
 	token := strings.Replace(req.Header.Get("authorization"), "Bearer ", "", 1)
-	if (token != "ghi" && token != "jkl") || token == p.lastToken {
+	if p.envelopeAuthErrors > 0 {
+		p.envelopeAuthErrors--
+		delete(p.validTokens, token)
+		_, _ = io.WriteString(w, "{\"success\": false, \"error\": { \"code\": 401, \"message\": \"Token expired\" } }")
+		return false
+	}
+	if p.uniqueTokens {
+		if !p.validTokens[token] || p.rejectTokens {
+			w.WriteHeader(401)
+			return false
+		}
+		delete(p.validTokens, token)
+		w.Header().Add("authorization", "Bearer "+p.issueToken())
+		return true
+	}
+
+	if (token != "ghi" && token != "jkl") || (token == p.lastToken && !p.disableRotation) || p.rejectTokens {
 		w.WriteHeader(401)
 		return false
 	}
 
 	// token rotation
-	if token == "ghi" {
-		w.Header().Add("authorization", "Bearer jkl")
-	} else {
-		w.Header().Add("authorization", "Bearer ghi")
+	if !p.disableRotation {
+		if token == "ghi" {
+			w.Header().Add("authorization", "Bearer jkl")
+		} else {
+			w.Header().Add("authorization", "Bearer ghi")
+		}
 	}
 
 	p.lastToken = token
@@ -45,19 +269,47 @@ func (p *PersonioMock) authenticate(w http.ResponseWriter, req *http.Request) bo
 // PersonioMockHandler is a simple handler that emulates parts of the Personio API with anonymous fake data for testing
 func (p *PersonioMock) PersonioMockHandler(w http.ResponseWriter, req *http.Request) {
 
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
 	method := req.Method
 	path := req.URL.Path
-	if method == http.MethodPost && (path == "/auth" || path == "/auth/") {
+	p.lastRequestId = req.Header.Get(RequestIdHeader)
+
+	if len(p.unavailable) > 0 {
+		if p.unavailable[0] != "" {
+			w.Header().Set("Retry-After", p.unavailable[0])
+		}
+		p.unavailable = p.unavailable[1:]
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if (method == http.MethodPost && (path == "/auth" || path == "/auth/")) || (method == http.MethodGet && path == "/gateway/token") {
 
+		p.lastAuthContentType = req.Header.Get("Content-Type")
 		err := req.ParseForm()
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		} else if req.FormValue("client_id") == "abc" && req.FormValue("client_secret") == "def" {
-			_, _ = io.WriteString(w, "{\"success\": true, \"data\": { \"token\": \"ghi\" } }")
+			p.authCount++
+			token := "ghi"
+			if p.uniqueTokens {
+				token = p.issueToken()
+			}
+			expiresIn := ""
+			if p.authExpiresIn > 0 {
+				expiresIn = fmt.Sprintf(", \"expires_in\": %d", p.authExpiresIn)
+			}
+			_, _ = io.WriteString(w, "{\"success\": true, \"data\": { \"token\": \""+token+"\""+expiresIn+" } }")
 		} else {
+			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, "{\"success\": false, \"error\": { \"code\": 0, \"message\": \"Wrong credentials\" } }")
 		}
+	} else if method == http.MethodGet && path == "/health" {
+
+		_, _ = io.WriteString(w, "{\"status\": \"ok\"}")
 	} else if method == http.MethodGet && (path == "/company/time-offs" || path == "/company/time-offs/") {
 
 		if !p.authenticate(w, req) {
@@ -95,32 +347,20 @@ func (p *PersonioMock) PersonioMockHandler(w http.ResponseWriter, req *http.Requ
 		offset, offsetErr := strconv.Atoi(offsetArg)
 		startArg := query.Get("start_date")
 		endArg := query.Get("end_date")
-		var start time.Time
-		var end time.Time
-		var errStart error
-		var errEnd error
-		if startArg != "" {
-			start, errStart = time.Parse(queryDateFormat, startArg)
-			// Personio seems to report more events around the selected start date
-			// try to match that behavior
-			start = start.Add(time.Second*24*60*60*-1 - 1)
-		} else {
-			start = time.Time{}
+		_, errStart := util.ParsePersonioDate(startArg)
+		if startArg == "" {
+			errStart = nil
 		}
-
-		if endArg != "" {
-			end, errEnd = time.Parse(queryDateFormat, endArg)
-			// Personio seems to report more events around the selected end date
-			end = end.Add(time.Second*24*60*60 - 1)
-		} else {
-			end = util.PersonioDateMax
+		_, errEnd := util.ParsePersonioDate(endArg)
+		if endArg == "" {
+			errEnd = nil
 		}
 
 		if limitArg == "" {
 			limit = pagingMaxLimit
 		}
 
-		if errStart != nil || errEnd != nil || end.Before(start) ||
+		if errStart != nil || errEnd != nil || (startArg != "" && endArg != "" && endArg < startArg) ||
 			(limitArg != "" && (limitErr != nil || limit > pagingMaxLimit || limit < 1)) ||
 			(offsetArg != "" && (offsetErr != nil || offset < 0)) {
 			w.WriteHeader(http.StatusBadRequest)
@@ -131,12 +371,7 @@ func (p *PersonioMock) PersonioMockHandler(w http.ResponseWriter, req *http.Requ
 		filteredTimeOffsResult := timeOffsResultBody{Success: result.Success, Error: result.Error, Data: make([]timeOffContainer, 0)}
 		count := 0
 		for i := range result.Data {
-			offStart := result.Data[i].Attributes.StartDate
-			offEnd := result.Data[i].Attributes.EndDate
-			// "end" empty and time-off ends after "start"
-			// OR overlapping start/end and time-off ranges
-			// (empty start and time-off before end is handled implicitly by start being zero == epoch)
-			if util.GetTimeIntersection(offStart, offEnd, start, end) >= 0 {
+			if overlapsQueryDates(result.Data[i].Attributes.StartDate, result.Data[i].Attributes.EndDate, startArg, endArg) {
 				if count >= offset {
 					filteredTimeOffsResult.Data = append(filteredTimeOffsResult.Data, result.Data[i])
 				}
@@ -155,135 +390,470 @@ func (p *PersonioMock) PersonioMockHandler(w http.ResponseWriter, req *http.Requ
 		}
 
 		_, _ = w.Write(timeOffResponseBody)
-	} else if method == http.MethodGet && strings.HasPrefix(path, "/company/employees") {
+	} else if method == http.MethodPost && (path == "/company/time-offs" || path == "/company/time-offs/") {
 
 		if !p.authenticate(w, req) {
 			return
 		}
 
-		if path == "/company/employees" || path == "/company/employees/" {
-			employeesResponseBody, err := os.ReadFile(filepath.Join("testdata", "employees.json"))
-			if err != nil {
-				fmt.Printf("Failed to read employees test data file: %s\n", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+		rawBody, err := io.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		p.lastTimeOffBody = rawBody
 
-			_, _ = w.Write(employeesResponseBody)
-		} else {
-			pathSegments := strings.FieldsFunc(path, func(char rune) bool { return char == '/' })
-			if len(pathSegments) > 3 {
-				w.WriteHeader(http.StatusNotFound)
-				return
-			}
+		// half-day flags may be sent as false/true or 0/1
+		var body struct {
+			createTimeOffBody
+			HalfDayStart PersonioBool `json:"half_day_start"`
+			HalfDayEnd   PersonioBool `json:"half_day_end"`
+		}
+		err = json.Unmarshal(rawBody, &body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
 
-			id, err := strconv.ParseInt(pathSegments[len(pathSegments)-1], 10, 64)
-			if err != nil {
-				w.WriteHeader(http.StatusNotFound)
-				return
-			}
+		start, errStart := util.ParsePersonioDate(body.StartDate)
+		end, errEnd := util.ParsePersonioDate(body.EndDate)
+		if errStart != nil || errEnd != nil || end.Before(start) || body.EmployeeId == 0 || body.TimeOffTypeId == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
 
-			employeeResponseBody, err := os.ReadFile(filepath.Join("testdata", fmt.Sprintf("employee-%d.json", id)))
-			if err != nil {
-				if os.IsNotExist(err) {
-					w.WriteHeader(http.StatusNotFound)
-				} else {
-					fmt.Printf("Failed to read employee %d test data file: %s\n", id, err)
-					w.WriteHeader(http.StatusInternalServerError)
-				}
-				return
-			}
+		if body.EmployeeId == 7161253 && !start.Before(makeTime("2022-09-05T00:00:00Z")) && !start.After(makeTime("2022-09-09T00:00:00Z")) {
+			// overlaps existing time-off 125814620
+			_, _ = io.WriteString(w, "{\"success\": false, \"error\": { \"code\": 400, \"message\": \"mega@giantswarm.io already has an absence in this period\" } }")
+			return
+		}
 
-			_, _ = w.Write(employeeResponseBody)
+		var created timeOffContainer
+		created.Type = "TimeOffPeriod"
+		created.Attributes.Id = 130000000 + body.EmployeeId%1000
+		created.Attributes.Status = "approved"
+		created.Attributes.Comment = body.Comment
+		created.Attributes.StartDate = start
+		created.Attributes.EndDate = end
+		created.Attributes.HalfDayStart = body.HalfDayStart
+		created.Attributes.HalfDayEnd = body.HalfDayEnd
+		created.Attributes.TimeOffType.Type = "TimeOffType"
+		created.Attributes.TimeOffType.Attributes.Id = body.TimeOffTypeId
+
+		createdResponseBody, err := json.Marshal(map[string]interface{}{"success": true, "data": created})
+		if err != nil {
+			fmt.Printf("Failed to marshall created time-off: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
 		}
 
-	} else {
-		w.WriteHeader(http.StatusNotFound)
-	}
-}
+		if !p.omitLocation {
+			w.Header().Set("Location", fmt.Sprintf("/company/time-offs/%d", created.Attributes.Id))
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(createdResponseBody)
+	} else if (method == http.MethodGet || method == http.MethodPatch) && strings.HasPrefix(path, "/company/time-offs/") {
 
-// testServer is a mocked test server for Personio client testing
-// implements io.Closer
-type testServer struct {
-	mock   PersonioMock
-	port   int
-	closer io.Closer
-}
+		if !p.authenticate(w, req) {
+			return
+		}
 
-// Close closes this testServer instance
-func (t *testServer) Close() error {
-	if t.closer == nil {
-		return nil
-	}
+		id, err := strconv.ParseInt(strings.TrimPrefix(path, "/company/time-offs/"), 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
 
-	return t.closer.Close()
-}
+		timeOff, err := p.findTimeOff(id)
+		if err != nil {
+			fmt.Printf("Failed to read time-offs test data: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		} else if timeOff == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
 
-// newTestServer creates a new, running test server instance or returns an error
-func newTestServer() (testServer, error) {
+		if method == http.MethodPatch {
+			var body timeOffStatusBody
+			err = json.NewDecoder(req.Body).Decode(&body)
+			if err != nil || body.Status == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if p.timeOffStatuses == nil {
+				p.timeOffStatuses = map[int64]string{}
+			}
+			p.timeOffStatuses[id] = body.Status
+			timeOff.Attributes.Status = body.Status
+			if body.Comment != "" {
+				timeOff.Attributes.Comment = body.Comment
+			}
+		}
 
-	mock := PersonioMock{""}
+		timeOffResponseBody, err := json.Marshal(map[string]interface{}{"success": true, "data": timeOff})
+		if err != nil {
+			fmt.Printf("Failed to marshall time-off: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
 
-	listener, err := net.Listen("tcp", "localhost:0")
-	if err != nil {
-		return testServer{}, err
-	}
+		_, _ = w.Write(timeOffResponseBody)
+	} else if method == http.MethodGet && (path == "/company/attendances" || path == "/company/attendances/") {
 
-	go func() {
-		srv := &http.Server{
-			Handler:           http.HandlerFunc(mock.PersonioMockHandler),
-			ReadHeaderTimeout: time.Duration(30) * time.Second,
+		if !p.authenticate(w, req) {
+			return
 		}
-		_ = srv.Serve(listener)
-	}()
 
-	port := listener.Addr().(*net.TCPAddr).Port
+		query := req.URL.Query()
+		start, errStart := util.ParsePersonioDate(query.Get("start_date"))
+		end, errEnd := util.ParsePersonioDate(query.Get("end_date"))
+		if errStart != nil || errEnd != nil || end.Before(start) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
 
-	return testServer{mock: PersonioMock{""}, port: port, closer: listener}, nil
-}
+		employeeIds := map[int64]bool{}
+		for _, employeeArg := range query["employees[]"] {
+			id, err := strconv.ParseInt(employeeArg, 10, 64)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			employeeIds[id] = true
+		}
 
-// makeTime Forces parsing a timestamp in ISO8601 RFC3339 format and returns Time{} on any error
-func makeTime(ts string) time.Time {
-	t, _ := time.Parse(time.RFC3339, ts)
-	return t
-}
+		// remove entries outside range or of other employees
+		p.writeFixturePage(w, req, "attendances.json", func(element json.RawMessage) bool {
+			var attendance attendanceContainer
+			if json.Unmarshal(element, &attendance) != nil {
+				return false
+			}
+			date, err := util.ParsePersonioDate(attendance.Attributes.Date)
+			if err != nil || date.Before(start) || date.After(end) {
+				return false
+			}
+			return len(employeeIds) == 0 || employeeIds[attendance.Attributes.EmployeeId]
+		})
+	} else if method == http.MethodPost && (path == "/company/attendances" || path == "/company/attendances/") {
 
-// makeDuration Forces parsing a duration and returns Duration{} on any error
-func makeDuration(duration string) time.Duration {
-	d, _ := time.ParseDuration(duration)
-	return d
-}
+		if !p.authenticate(w, req) {
+			return
+		}
 
-type authTestCase struct {
-	creds      Credentials
-	wantToken  string
-	wantStatus int
-}
+		var err error
+		p.lastAttendancesBody, err = io.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
 
-func TestClient_Authenticate(t *testing.T) {
-	authTestCases := []authTestCase{
-		{creds: Credentials{ClientId: "abc", ClientSecret: "def"}, wantToken: "ghi", wantStatus: 0},
-		{creds: Credentials{ClientId: "abc", ClientSecret: "crap"}, wantToken: "", wantStatus: http.StatusUnauthorized},
-	}
+		var body struct {
+			Attendances []map[string]interface{} `json:"attendances"`
+		}
+		err = json.Unmarshal(p.lastAttendancesBody, &body)
+		if err != nil || len(body.Attendances) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
 
-	server, err := newTestServer()
-	if err != nil {
-		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
-		return
-	}
+		ids := make([]string, len(body.Attendances))
+		for i := range body.Attendances {
+			ids[i] = strconv.Itoa(81231000 + i)
+		}
+		_, _ = io.WriteString(w, "{\"success\": true, \"data\": { \"id\": ["+strings.Join(ids, ", ")+"], \"message\": \"success\" } }")
+	} else if (method == http.MethodPatch || method == http.MethodDelete) && strings.HasPrefix(path, "/company/attendances/") {
 
-	defer func() {
-		_ = server.Close()
-	}()
+		if !p.authenticate(w, req) {
+			return
+		}
 
-	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
-	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
-	if err != nil {
-		t.Errorf("Failed to create Personio API v1 client: %s", err)
-		return
-	}
+		// the fixture attendances range from 81230001 to 81230007
+		id, err := strconv.ParseInt(strings.TrimPrefix(path, "/company/attendances/"), 10, 64)
+		if err != nil || id < 81230001 || id > 81230007 || p.deletedAttendances[id] {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, "{\"success\": false, \"error\": { \"code\": 404, \"message\": \"Attendance not found\" } }")
+			return
+		}
 
-	for testNumber, testCase := range authTestCases {
+		if method == http.MethodDelete {
+			if p.deletedAttendances == nil {
+				p.deletedAttendances = map[int64]bool{}
+			}
+			p.deletedAttendances[id] = true
+			_, _ = io.WriteString(w, "{\"success\": true, \"data\": { \"message\": \"The attendance period was deleted.\" } }")
+			return
+		}
+
+		p.lastAttendancesBody, err = io.ReadAll(req.Body)
+		var patch map[string]interface{}
+		if err != nil || json.Unmarshal(p.lastAttendancesBody, &patch) != nil || len(patch) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if breakMinutes, ok := patch["break"].(float64); ok && breakMinutes > 600 {
+			_, _ = io.WriteString(w, "{\"success\": false, \"error\": { \"code\": 0, \"message\": \"Break exceeds working time\" } }")
+			return
+		}
+		_, _ = io.WriteString(w, "{\"success\": true, \"data\": { \"id\": "+strconv.FormatInt(id, 10)+", \"message\": \"success\" } }")
+	} else if method == http.MethodGet && (path == "/company/absence-periods" || path == "/company/absence-periods/") {
+
+		if !p.authenticate(w, req) {
+			return
+		}
+
+		query := req.URL.Query()
+		startArg := query.Get("start_date")
+		endArg := query.Get("end_date")
+		_, errStart := util.ParsePersonioDate(startArg)
+		_, errEnd := util.ParsePersonioDate(endArg)
+		if (startArg != "" && errStart != nil) || (endArg != "" && errEnd != nil) || (startArg != "" && endArg != "" && endArg < startArg) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// remove entries outside range
+		p.writeFixturePage(w, req, "absence-periods.json", func(element json.RawMessage) bool {
+			var absence absenceContainer
+			if json.Unmarshal(element, &absence) != nil {
+				return false
+			}
+			return overlapsQueryDates(absence.Attributes.StartDate, absence.Attributes.EndDate, startArg, endArg)
+		})
+	} else if method == http.MethodGet && (path == "/company/documents" || path == "/company/documents/") {
+
+		if !p.authenticate(w, req) {
+			return
+		}
+
+		employeeId, err := strconv.ParseInt(req.URL.Query().Get("employee_id"), 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		p.writeFixturePage(w, req, "documents.json", func(element json.RawMessage) bool {
+			var document documentContainer
+			return json.Unmarshal(element, &document) == nil && document.Attributes.EmployeeId == employeeId
+		})
+	} else if method == http.MethodGet && (path == "/company/time-off-types" || path == "/company/time-off-types/") {
+
+		if !p.authenticate(w, req) {
+			return
+		}
+
+		p.timeOffTypesCount++
+		p.writeFixturePage(w, req, "time-off-types.json", nil)
+	} else if method == http.MethodGet && strings.HasPrefix(path, "/company/custom-reports/reports/") {
+
+		if !p.authenticate(w, req) {
+			return
+		}
+
+		// report "headcount" exists, "legacy" is served as HTML, any other fails with a JSON error
+		switch strings.TrimPrefix(path, "/company/custom-reports/reports/") {
+		case "headcount":
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			_, _ = io.WriteString(w, "Office,Headcount\nBerlin,1\nCologne,1\n")
+		case "legacy":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = io.WriteString(w, "<html></html>")
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, "{\"success\": false, \"error\": {\"code\": 0, \"message\": \"report not found\"}}")
+		}
+	} else if method == http.MethodHead && strings.HasPrefix(path, "/company/employees/") && strings.HasSuffix(path, "/profile-picture") {
+
+		if !p.authenticate(w, req) {
+			return
+		}
+
+		// only El Gonzo uploaded a picture
+		if path != "/company/employees/6205887/profile-picture" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+	} else if method == http.MethodGet && strings.HasPrefix(path, "/company/employees/") && strings.HasSuffix(path, "/absences/balance") {
+
+		if !p.authenticate(w, req) {
+			return
+		}
+
+		id, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(path, "/company/employees/"), "/absences/balance"), 10, 64)
+		if err != nil || (id != 6205887 && id != 7161253) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if p.forbiddenEmployees[id] {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = io.WriteString(w, "{\"success\": true, \"data\": [{\"id\": 155627, \"name\": \"Paid vacation\", \"balance\": 20}]}")
+	} else if method == http.MethodPatch && strings.HasPrefix(path, "/company/employees/") {
+
+		if !p.authenticate(w, req) {
+			return
+		}
+
+		id, err := strconv.ParseInt(strings.TrimPrefix(path, "/company/employees/"), 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var body employeePatchBody
+		err = json.NewDecoder(req.Body).Decode(&body)
+		if err != nil || len(body.Employee) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if p.employeePatches == nil {
+			p.employeePatches = map[int64][]map[string]interface{}{}
+		}
+		p.employeePatches[id] = append(p.employeePatches[id], body.Employee)
+		_, _ = io.WriteString(w, "{\"success\": true, \"data\": { \"message\": \"success\" } }")
+	} else if method == http.MethodGet && strings.HasPrefix(path, "/company/employees") {
+
+		if !p.authenticate(w, req) {
+			return
+		}
+
+		if (path == "/company/employees" || path == "/company/employees/") && req.URL.Query().Has("email") {
+			email := req.URL.Query().Get("email")
+			p.writeFixturePage(w, req, "employees.json", func(element json.RawMessage) bool {
+				var employee Employee
+				if json.Unmarshal(element, &employee) != nil {
+					return false
+				}
+				value := employee.GetStringAttribute("email")
+				return value != nil && *value == email
+			})
+		} else if path == "/company/employees" || path == "/company/employees/" {
+			p.writeFixturePage(w, req, "employees.json", nil)
+		} else {
+			pathSegments := strings.FieldsFunc(path, func(char rune) bool { return char == '/' })
+			if len(pathSegments) > 3 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			id, err := strconv.ParseInt(pathSegments[len(pathSegments)-1], 10, 64)
+			if err != nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			employeeResponseBody, err := os.ReadFile(filepath.Join("testdata", fmt.Sprintf("employee-%d.json", id)))
+			if err != nil {
+				if os.IsNotExist(err) {
+					w.WriteHeader(http.StatusNotFound)
+				} else {
+					fmt.Printf("Failed to read employee %d test data file: %s\n", id, err)
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+				return
+			}
+
+			employeeResponseBody, err = p.patchEmployee(id, employeeResponseBody)
+			if err != nil {
+				fmt.Printf("Failed to patch employee %d test data: %s\n", id, err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			_, _ = w.Write(employeeResponseBody)
+		}
+
+	} else {
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// testServer is a mocked test server for Personio client testing
+// implements io.Closer
+type testServer struct {
+	mock   *PersonioMock
+	port   int
+	closer io.Closer
+}
+
+// Close closes this testServer instance
+func (t *testServer) Close() error {
+	if t.closer == nil {
+		return nil
+	}
+
+	return t.closer.Close()
+}
+
+// newTestServer creates a new, running test server instance or returns an error
+func newTestServer() (testServer, error) {
+
+	mock := &PersonioMock{}
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return testServer{}, err
+	}
+
+	go func() {
+		srv := &http.Server{
+			Handler:           http.HandlerFunc(mock.PersonioMockHandler),
+			ReadHeaderTimeout: time.Duration(30) * time.Second,
+		}
+		_ = srv.Serve(listener)
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	return testServer{mock: mock, port: port, closer: listener}, nil
+}
+
+// makeTime Forces parsing a timestamp in ISO8601 RFC3339 format and returns Time{} on any error
+func makeTime(ts string) time.Time {
+	t, _ := time.Parse(time.RFC3339, ts)
+	return t
+}
+
+// makeDuration Forces parsing a duration and returns Duration{} on any error
+func makeDuration(duration string) time.Duration {
+	d, _ := time.ParseDuration(duration)
+	return d
+}
+
+type authTestCase struct {
+	creds      Credentials
+	wantToken  string
+	wantStatus int
+}
+
+func TestClient_Authenticate(t *testing.T) {
+	authTestCases := []authTestCase{
+		{creds: Credentials{ClientId: "abc", ClientSecret: "def"}, wantToken: "ghi", wantStatus: 0},
+		{creds: Credentials{ClientId: "abc", ClientSecret: "crap"}, wantToken: "", wantStatus: http.StatusUnauthorized},
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	for testNumber, testCase := range authTestCases {
 
 		token, err := personio.Authenticate(testCase.creds.ClientId, testCase.creds.ClientSecret)
 
@@ -617,3 +1187,1227 @@ func TestClient_GetTimeOffsMapped(t *testing.T) {
 		}
 	}
 }
+
+// testLogger records all messages written to it
+type testLogger struct {
+	messages []string
+}
+
+// Printf records a formatted message
+func (l *testLogger) Printf(format string, v ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+func TestClient_RotationWarning(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	logger := &testLogger{}
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithLogger(logger))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	_, err = personio.GetEmployees()
+	if err != nil {
+		t.Errorf("Failed to query all employees: %s", err)
+		return
+	}
+
+	if len(logger.messages) != 0 {
+		t.Errorf("Expected no warnings while tokens are rotated, got %v", logger.messages)
+	}
+
+	server.mock.disableRotation = true
+	for i := 0; i < 2; i++ {
+		_, err = personio.GetEmployees()
+		if err != nil {
+			t.Errorf("[%d] Failed to query all employees: %s", i, err)
+			return
+		}
+	}
+
+	if len(logger.messages) != 1 {
+		t.Errorf("Expected exactly one warning about missing token rotation, got %v", logger.messages)
+	}
+}
+
+func TestClient_TokenRotationLoop(t *testing.T) {
+
+	// fresh tokens hit a temporary outage, rotated ones are rejected while being rotated again
+	rotations := 0
+	requests := 0
+	auths := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/auth" {
+			auths++
+			_, _ = fmt.Fprintf(w, `{"success":true,"data":{"token":"fresh-%d"}}`, auths)
+			return
+		}
+		requests++
+		rotations++
+		w.Header().Set("authorization", fmt.Sprintf("Bearer rotated-%d", rotations))
+		if strings.HasPrefix(req.Header.Get("Authorization"), "Bearer fresh-") {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), server.URL, personioCredentials, WithRetries(10))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	_, err = personio.GetEmployee(6205887)
+	if !errors.Is(err, ErrTokenRotationLoop) {
+		t.Errorf("Expected ErrTokenRotationLoop, got %v", err)
+	}
+	var statusErr StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected StatusError with code 401, got %v", err)
+	}
+
+	// the initial token and the one fetched after the first rejected rotation are fresh
+	if auths != maxRejectedRotations {
+		t.Errorf("Expected %d authentications, got %d", maxRejectedRotations, auths)
+	}
+	if requests != 2*maxRejectedRotations {
+		t.Errorf("Expected %d requests, got %d", 2*maxRejectedRotations, requests)
+	}
+}
+
+func TestClient_ReauthenticateAfterRetry(t *testing.T) {
+
+	// the first fresh token hits a temporary outage, the token rotated meanwhile has been invalidated
+	requests := 0
+	auths := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/auth" {
+			auths++
+			_, _ = fmt.Fprintf(w, `{"success":true,"data":{"token":"fresh-%d"}}`, auths)
+			return
+		}
+		requests++
+		w.Header().Set("authorization", fmt.Sprintf("Bearer rotated-%d", requests))
+		switch {
+		case req.Header.Get("Authorization") == "Bearer fresh-1":
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case strings.HasPrefix(req.Header.Get("Authorization"), "Bearer fresh-"):
+			_, _ = io.WriteString(w, `{"success":true,"data":{"type":"Employee","attributes":{}}}`)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), server.URL, personioCredentials, WithRetries(1))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	// the 401 after the retry must fetch a fresh token rather than use the rotated one it returned
+	_, err = personio.GetEmployee(6205887)
+	if err != nil {
+		t.Errorf("Expected re-authentication after 401, got error: %s", err)
+	}
+	if auths != 2 || requests != 3 {
+		t.Errorf("Expected 2 authentications and 3 requests, got %d and %d", auths, requests)
+	}
+}
+
+func TestPersonioBool_MarshalJSON(t *testing.T) {
+
+	for _, value := range []bool{false, true} {
+		encoded, err := json.Marshal(PersonioBool(value))
+		if err != nil || string(encoded) != strconv.FormatBool(value) {
+			t.Errorf("Expected %t encoded as %t, got %s (%v)", value, value, encoded, err)
+		}
+		var decoded PersonioBool
+		if err = json.Unmarshal(encoded, &decoded); err != nil || bool(decoded) != value {
+			t.Errorf("Expected %s decoded as %t, got %t (%v)", encoded, value, decoded, err)
+		}
+
+		encoded, err = json.Marshal(NumericBool(value))
+		wantNumeric := "0"
+		if value {
+			wantNumeric = "1"
+		}
+		if err != nil || string(encoded) != wantNumeric {
+			t.Errorf("Expected numeric %t encoded as %s, got %s (%v)", value, wantNumeric, encoded, err)
+		}
+		var decodedNumeric NumericBool
+		if err = json.Unmarshal(encoded, &decodedNumeric); err != nil || bool(decodedNumeric) != value {
+			t.Errorf("Expected %s decoded as %t, got %t (%v)", encoded, value, decodedNumeric, err)
+		}
+	}
+
+	testCases := []struct {
+		opts []Option
+		want string
+	}{
+		{opts: nil, want: `"half_day_start":false,"half_day_end":true`},
+		{opts: []Option{WithNumericBools()}, want: `"half_day_start":0,"half_day_end":1`},
+	}
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	for testCaseNumber, testCase := range testCases {
+		server, err := newTestServer()
+		if err != nil {
+			t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+			return
+		}
+
+		personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, testCase.opts...)
+		if err != nil {
+			_ = server.Close()
+			t.Errorf("[%d] Failed to create Personio API v1 client: %s", testCaseNumber, err)
+			continue
+		}
+
+		timeOff, _, err := personio.CreateTimeOff(CreateTimeOffRequest{EmployeeId: 6205887, TimeOffTypeId: 155627, StartDate: makeTime("2022-10-03T00:00:00Z"), EndDate: makeTime("2022-10-04T00:00:00Z"), HalfDayEnd: true})
+		_ = server.Close()
+		if err != nil {
+			t.Errorf("[%d] Failed to create time-off: %s", testCaseNumber, err)
+			continue
+		}
+		if !strings.Contains(string(server.mock.lastTimeOffBody), testCase.want) {
+			t.Errorf("[%d] Expected body containing %s, got %s", testCaseNumber, testCase.want, server.mock.lastTimeOffBody)
+		}
+		if timeOff.HalfDayStart || !timeOff.HalfDayEnd {
+			t.Errorf("[%d] Expected half-day flags to round-trip, got %t/%t", testCaseNumber, timeOff.HalfDayStart, timeOff.HalfDayEnd)
+		}
+	}
+}
+
+type createTimeOffTestCase struct {
+	request      CreateTimeOffRequest
+	omitLocation bool
+	wantId       int64
+	wantStatus   int
+}
+
+func TestClient_CreateTimeOff(t *testing.T) {
+
+	createTimeOffCases := []createTimeOffTestCase{
+		{request: CreateTimeOffRequest{EmployeeId: 7161253, TimeOffTypeId: 155627, StartDate: makeTime("2022-10-03T00:00:00Z"), EndDate: makeTime("2022-10-04T00:00:00Z"), Comment: "new"}, wantId: 130000253},
+		{request: CreateTimeOffRequest{EmployeeId: 6205887, TimeOffTypeId: 155627, StartDate: makeTime("2022-10-03T00:00:00Z"), EndDate: makeTime("2022-10-03T00:00:00Z"), HalfDayEnd: true}, omitLocation: true, wantId: 130000887},
+		{request: CreateTimeOffRequest{EmployeeId: 6205887, TimeOffTypeId: 155627, StartDate: makeTime("2022-10-04T00:00:00Z"), EndDate: makeTime("2022-10-03T00:00:00Z")}, wantStatus: http.StatusBadRequest},
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	baseUrl := fmt.Sprintf("http://localhost:%d", server.port)
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), baseUrl, personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	for testNumber, testCase := range createTimeOffCases {
+
+		server.mock.omitLocation = testCase.omitLocation
+		timeOff, location, err := personio.CreateTimeOff(testCase.request)
+
+		if testCase.wantStatus != 0 {
+			if err == nil {
+				t.Errorf("[%d] Expected error code %d but none returned", testNumber, testCase.wantStatus)
+			} else {
+				switch e := err.(type) {
+				case Error:
+					if e.Status() != testCase.wantStatus {
+						t.Errorf("[%d] Expected error code %d but got %d: %s", testNumber, testCase.wantStatus, e.Status(), e)
+					}
+					err = nil // handled
+				}
+			}
+		}
+		if err != nil {
+			t.Errorf("[%d] Failed to create time-off: %s", testNumber, err)
+			continue
+		}
+
+		if testCase.wantId != 0 {
+			if timeOff == nil || timeOff.Id != testCase.wantId {
+				t.Errorf("[%d] Expected created time-off with ID %d, got %v", testNumber, testCase.wantId, timeOff)
+				continue
+			}
+
+			wantLocation := fmt.Sprintf("%s/company/time-offs/%d", baseUrl, testCase.wantId)
+			if location != wantLocation {
+				t.Errorf("[%d] Expected location \"%s\", got \"%s\"", testNumber, wantLocation, location)
+			}
+		}
+	}
+}
+
+func TestClient_ReauthenticateOnUnauthorized(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	// start with a token that has been invalidated server-side
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def", AccessToken: "revoked"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	employee, err := personio.GetEmployee(6205887)
+	if err != nil {
+		t.Errorf("Expected re-authentication after 401, got error: %s", err)
+		return
+	}
+	if employee == nil {
+		t.Errorf("Expected employee, got nil")
+	}
+	if server.mock.authCount != 1 {
+		t.Errorf("Expected exactly 1 authentication, got %d", server.mock.authCount)
+	}
+
+	// a second 401 must not be retried again
+	server.mock.rejectTokens = true
+	server.mock.authCount = 0
+	_, err = personio.GetEmployee(6205887)
+	var statusErr StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected error code %d, got %v", http.StatusUnauthorized, err)
+	}
+	if server.mock.authCount != 1 {
+		t.Errorf("Expected exactly 1 re-authentication, got %d", server.mock.authCount)
+	}
+}
+
+func TestClient_ReauthenticateOnEnvelopeAuthError(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+	server.mock.uniqueTokens = true
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	// the token expires, reported with status 200 and an auth error in the body
+	server.mock.envelopeAuthErrors = 1
+	employee, err := personio.GetEmployee(6205887)
+	if err != nil {
+		t.Errorf("Expected re-authentication after auth error, got error: %s", err)
+		return
+	}
+	if employee == nil {
+		t.Errorf("Expected employee, got nil")
+	}
+	if server.mock.authCount != 2 {
+		t.Errorf("Expected 2 authentications, got %d", server.mock.authCount)
+	}
+
+	// a second auth error must not be retried again
+	server.mock.envelopeAuthErrors = 2
+	_, err = personio.GetEmployee(6205887)
+	if !IsAuthError(err) {
+		t.Errorf("Expected auth error, got %v", err)
+	}
+	var personioErr PersonioError
+	if !errors.As(err, &personioErr) || personioErr.Message != "Token expired" {
+		t.Errorf("Expected PersonioError \"Token expired\", got %v", err)
+	}
+	if server.mock.authCount != 3 {
+		t.Errorf("Expected 3 authentications, got %d", server.mock.authCount)
+	}
+}
+
+func TestAuth_Expiry(t *testing.T) {
+
+	now := makeTime("2022-09-05T12:00:00Z")
+	testCases := []struct {
+		body string
+		want time.Time
+	}{
+		{`{"data": {"token": "abc"}}`, makeTime("2022-09-05T13:00:00Z")},
+		{`{"data": {"token": "abc", "expires_in": 600}}`, makeTime("2022-09-05T12:10:00Z")},
+		{`{"data": {"token": "abc", "expires_in": 600, "expires_at": 1662386400}}`, makeTime("2022-09-05T14:00:00Z")},
+	}
+
+	for testNumber, testCase := range testCases {
+		var auth Auth
+		err := json.Unmarshal([]byte(testCase.body), &auth)
+		if err != nil {
+			t.Errorf("[%d] Failed to unmarshal auth: %s", testNumber, err)
+			continue
+		}
+
+		got := auth.Expiry(now, time.Hour)
+		if !got.Equal(testCase.want) {
+			t.Errorf("[%d] Expected expiry %s, got %s", testNumber, testCase.want.Format(time.RFC3339), got.Format(time.RFC3339))
+		}
+	}
+}
+
+func TestClient_AuthenticateWithExpiry(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+	server.mock.uniqueTokens = true
+	server.mock.authExpiresIn = 600
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+	now := makeTime("2022-09-05T12:00:00Z")
+	personio.clock = func() time.Time { return now }
+
+	token, expiry, err := personio.AuthenticateWithExpiry("abc", "def")
+	if err != nil {
+		t.Errorf("Failed to authenticate: %s", err)
+		return
+	}
+	if token == "" {
+		t.Errorf("Expected token, got none")
+	}
+	if want := now.Add(10 * time.Minute); !expiry.Equal(want) {
+		t.Errorf("Expected expiry %s, got %s", want.Format(time.RFC3339), expiry.Format(time.RFC3339))
+	}
+
+	// the rotated token inherits the expiry and is reused until then
+	for i := 0; i < 2; i++ {
+		_, err = personio.GetEmployee(6205887)
+		if err != nil {
+			t.Errorf("[%d] Failed to get employee: %s", i, err)
+			return
+		}
+	}
+	if server.mock.authCount != 2 {
+		t.Errorf("Expected 2 authentications, got %d", server.mock.authCount)
+	}
+
+	// the expired token is dropped instead of being sent
+	now = now.Add(10 * time.Minute)
+	_, err = personio.GetEmployee(6205887)
+	if err != nil {
+		t.Errorf("Failed to get employee: %s", err)
+		return
+	}
+	if server.mock.authCount != 3 {
+		t.Errorf("Expected 3 authentications, got %d", server.mock.authCount)
+	}
+	if stats := personio.Stats(); stats.Unauthorized != 0 {
+		t.Errorf("Expected no rejected token, got %d", stats.Unauthorized)
+	}
+}
+
+func TestIsAuthError(t *testing.T) {
+
+	testCases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("personio: unexpected"), false},
+		{StatusError{Err: errors.New("unauthorized"), Code: http.StatusUnauthorized}, true},
+		{StatusError{Err: errors.New("forbidden"), Code: http.StatusForbidden}, false},
+		{PersonioError{Code: http.StatusUnauthorized, Message: "Token expired"}, true},
+		{PersonioError{Code: 0, Message: "Validation failed"}, false},
+		{fmt.Errorf("failed to fetch employee: %w", PersonioError{Code: http.StatusUnauthorized}), true},
+	}
+
+	for testNumber, testCase := range testCases {
+		got := IsAuthError(testCase.err)
+		if got != testCase.want {
+			t.Errorf("[%d] Expected %v, got %v for %v", testNumber, testCase.want, got, testCase.err)
+		}
+	}
+}
+
+func TestClient_WithRedaction(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	overlapping := CreateTimeOffRequest{EmployeeId: 7161253, TimeOffTypeId: 155627, StartDate: makeTime("2022-09-06T00:00:00Z"), EndDate: makeTime("2022-09-06T00:00:00Z")}
+	for _, redact := range []bool{false, true} {
+		personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+		personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithRedaction(redact))
+		if err != nil {
+			t.Errorf("Failed to create Personio API v1 client: %s", err)
+			return
+		}
+
+		_, _, err = personio.CreateTimeOff(overlapping)
+		if err == nil {
+			t.Errorf("[redact=%t] Expected error creating overlapping time-off", redact)
+			continue
+		}
+
+		containsEmail := strings.Contains(err.Error(), "mega@giantswarm.io")
+		if containsEmail == redact {
+			t.Errorf("[redact=%t] Unexpected error message: %s", redact, err)
+		}
+	}
+}
+
+func TestClient_RequestId(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(ContextWithRequestId(context.TODO(), "trace-123"), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	_, err = personio.GetEmployee(0xdeadbeef)
+	if server.mock.lastRequestId != "trace-123" {
+		t.Errorf("Expected request id \"trace-123\" to be sent, got \"%s\"", server.mock.lastRequestId)
+	}
+	var statusErr StatusError
+	if !errors.As(err, &statusErr) || statusErr.RequestId != "trace-123" || !strings.Contains(err.Error(), "trace-123") {
+		t.Errorf("Expected error to carry request id \"trace-123\", got %v", err)
+	}
+
+	// the mock hands out the same initial token to the next client
+	server.mock.lastToken = ""
+	personio, err = NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	_, err = personio.GetEmployee(6205887)
+	if err != nil {
+		t.Errorf("Failed to query employee: %s", err)
+	}
+	firstRequestId := server.mock.lastRequestId
+	_, _ = personio.GetEmployee(6205887)
+	if firstRequestId == "" || firstRequestId == server.mock.lastRequestId {
+		t.Errorf("Expected unique generated request ids, got \"%s\" and \"%s\"", firstRequestId, server.mock.lastRequestId)
+	}
+}
+
+type doTestCase struct {
+	method       string
+	relpath      string
+	authenticate bool
+	wantBody     string
+	wantStatus   int
+}
+
+func TestClient_Do(t *testing.T) {
+
+	doCases := []doTestCase{
+		{method: http.MethodGet, relpath: "/health", authenticate: false, wantBody: "{\"status\": \"ok\"}"},
+		{method: http.MethodGet, relpath: "/company/employees/6205887", authenticate: false, wantStatus: http.StatusUnauthorized},
+		{method: http.MethodGet, relpath: "/company/employees/6205887", authenticate: true, wantBody: "gonzo@giantswarm.io"},
+		{method: http.MethodDelete, relpath: "/health", authenticate: false, wantStatus: http.StatusNotFound},
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	for testNumber, testCase := range doCases {
+		authCount := server.mock.authCount
+		body, err := personio.Do(testCase.method, testCase.relpath, nil, testCase.authenticate)
+
+		if !testCase.authenticate && server.mock.authCount != authCount {
+			t.Errorf("[%d] Expected no authentication, got %d", testNumber, server.mock.authCount-authCount)
+		}
+
+		if testCase.wantStatus != 0 {
+			var statusErr StatusError
+			if !errors.As(err, &statusErr) || statusErr.Code != testCase.wantStatus {
+				t.Errorf("[%d] Expected error code %d, got %v", testNumber, testCase.wantStatus, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[%d] Failed to do request: %s", testNumber, err)
+			continue
+		}
+
+		if !strings.Contains(string(body), testCase.wantBody) {
+			t.Errorf("[%d] Expected body to contain \"%s\", got \"%s\"", testNumber, testCase.wantBody, body)
+		}
+	}
+}
+
+func TestAttribute_GetListOfMapsValue(t *testing.T) {
+
+	testCases := []struct {
+		value      interface{}
+		wantCities []string
+	}{
+		{value: []interface{}{map[string]interface{}{"type": "Address", "attributes": map[string]interface{}{"city": "Cologne"}}, map[string]interface{}{"city": "Berlin"}}, wantCities: []string{"Cologne", "Berlin"}},
+		{value: []interface{}{"Go", 42.0, map[string]interface{}{"city": "Berlin"}}, wantCities: []string{"Berlin"}},
+		{value: map[string]interface{}{"city": "Berlin"}, wantCities: []string{}},
+		{value: nil, wantCities: []string{}},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		attribute := Attribute{Type: "standard", Value: testCase.value}
+		got := attribute.GetListOfMapsValue()
+		if got == nil || len(got) != len(testCase.wantCities) {
+			t.Errorf("[%d] Expected %d elements, got %v", testCaseNumber, len(testCase.wantCities), got)
+			continue
+		}
+		for i, city := range testCase.wantCities {
+			if got[i]["city"] != city {
+				t.Errorf("[%d] Expected city %s, got %v", testCaseNumber, city, got[i]["city"])
+			}
+		}
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	employee, err := personio.GetEmployee(6205887)
+	if err != nil {
+		t.Errorf("Failed to query employee: %s", err)
+		return
+	}
+
+	addresses := employee.GetListOfMapsAttribute("dynamic_700553")
+	if len(addresses) != 2 || addresses[0]["city"] != "Cologne" || addresses[1]["zip"] != "10247" {
+		t.Errorf("Unexpected addresses %v", addresses)
+	}
+	if missing := employee.GetListOfMapsAttribute("dynamic_0"); missing == nil || len(missing) != 0 {
+		t.Errorf("Expected empty slice for missing attribute, got %v", missing)
+	}
+}
+
+func TestAttributeContainer_OrderedKeys(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	employee, err := personio.GetEmployee(6205887)
+	if err != nil {
+		t.Errorf("Failed to query employee: %s", err)
+		return
+	}
+
+	if employee.Type != "Employee" {
+		t.Errorf("Expected type Employee, got %q", employee.Type)
+	}
+
+	keys := employee.OrderedKeys()
+	if len(keys) != len(employee.Attributes) || strings.Join(keys[:4], ",") != "id,first_name,last_name,email" || keys[len(keys)-1] != "dynamic_700553" {
+		t.Errorf("Unexpected attribute order %v", keys)
+	}
+
+	// added keys follow sorted, removed keys are skipped
+	delete(employee.Attributes, "email")
+	employee.Attributes["b_added"] = Attribute{}
+	employee.Attributes["a_added"] = Attribute{}
+	keys = employee.OrderedKeys()
+	if strings.Join(keys[:3], ",") != "id,first_name,last_name" || strings.Join(keys[len(keys)-3:], ",") != "dynamic_700553,a_added,b_added" {
+		t.Errorf("Unexpected attribute order after modification %v", keys)
+	}
+
+	manual := AttributeContainer{Attributes: map[string]Attribute{"last_name": {}, "first_name": {}}}
+	if keys := manual.OrderedKeys(); strings.Join(keys, ",") != "first_name,last_name" {
+		t.Errorf("Expected sorted keys for manually built container, got %v", keys)
+	}
+
+	var timeOff TimeOff
+	err = json.Unmarshal([]byte(`{"id": 1, "employee": {"type": "Employee", "attributes": {"last_name": {}, "id": {}, "email": {}}}}`), &timeOff)
+	if err != nil {
+		t.Errorf("Failed to unmarshal time-off: %s", err)
+		return
+	}
+	if timeOff.Employee.Type != "Employee" || strings.Join(timeOff.Employee.OrderedKeys(), ",") != "last_name,id,email" {
+		t.Errorf("Unexpected embedded employee %v", timeOff.Employee)
+	}
+}
+
+func TestAttribute_GetTagValues(t *testing.T) {
+
+	testCases := []struct {
+		value interface{}
+		want  []string
+	}{
+		{value: "Go,Kubernetes", want: []string{"Go", "Kubernetes"}},
+		{value: []interface{}{"Go", "Kubernetes"}, want: []string{"Go", "Kubernetes"}},
+		{value: []interface{}{map[string]interface{}{"id": 31001.0, "name": "Go"}, map[string]interface{}{"id": 31002.0}}, want: []string{"Go"}},
+		{value: []interface{}{map[string]interface{}{"type": "Tag", "attributes": map[string]interface{}{"id": 31001.0, "name": "Go"}}}, want: []string{"Go"}},
+		{value: []interface{}{}, want: []string{}},
+		{value: 42.0, want: nil},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		attribute := Attribute{Type: "tags", Value: testCase.value}
+		got := attribute.GetTagValues()
+		if (got == nil) != (testCase.want == nil) || strings.Join(got, "|") != strings.Join(testCase.want, "|") {
+			t.Errorf("[%d] Expected tags %v, got %v", testCaseNumber, testCase.want, got)
+		}
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	employee, err := personio.GetEmployee(6205887)
+	if err != nil {
+		t.Errorf("Failed to query employee: %s", err)
+		return
+	}
+
+	skills := employee.GetTagAttribute("dynamic_700552")
+	if strings.Join(skills, ",") != "Go,Kubernetes" {
+		t.Errorf("Expected skills Go,Kubernetes, got %v", skills)
+	}
+}
+
+func TestAttribute_NilSafety(t *testing.T) {
+
+	malformed := []*Attribute{
+		nil,
+		{},
+		{Type: "integer"},
+		{Type: "integer", Value: "42"},
+		{Type: "decimal", Value: true},
+		{Type: "standard", Value: 42.0},
+		{Type: "tags", Value: map[string]interface{}{"name": "Go"}},
+		{Type: "date", Value: 42.0},
+		{Type: "date", Value: "not a date"},
+	}
+
+	for testCaseNumber, attribute := range malformed {
+		if value := attribute.GetIntValue(); value != nil {
+			t.Errorf("[%d] Expected nil int, got %d", testCaseNumber, *value)
+		}
+		if value := attribute.GetFloatValue(); value != nil {
+			t.Errorf("[%d] Expected nil float, got %f", testCaseNumber, *value)
+		}
+		if value := attribute.GetStringValue(); value != nil {
+			t.Errorf("[%d] Expected nil string, got %s", testCaseNumber, *value)
+		}
+		if value := attribute.GetTagValues(); value != nil {
+			t.Errorf("[%d] Expected nil tags, got %v", testCaseNumber, value)
+		}
+		if value := attribute.GetTimeValue(); value != nil {
+			t.Errorf("[%d] Expected nil time, got %s", testCaseNumber, value)
+		}
+		if value := attribute.GetMapValue(); len(value) != 0 {
+			t.Errorf("[%d] Expected empty map, got %v", testCaseNumber, value)
+		}
+	}
+
+	var container *AttributeContainer
+	if value := container.GetIntAttribute("id"); value != nil {
+		t.Errorf("Expected nil int from nil container, got %d", *value)
+	}
+	if value := container.GetMapAttribute("office"); len(value) != 0 {
+		t.Errorf("Expected empty map from nil container, got %v", value)
+	}
+
+	employee := Employee{}
+	if value := employee.GetStringAttribute("email"); value != nil {
+		t.Errorf("Expected nil string from empty employee, got %s", *value)
+	}
+}
+
+func TestClient_GetEmployeesWithParams(t *testing.T) {
+
+	testCases := []struct {
+		params  url.Values
+		wantIds []int64
+	}{
+		{params: nil, wantIds: []int64{6205887, 7161253}},
+		{params: url.Values{"email": {"mega@giantswarm.io"}}, wantIds: []int64{7161253}},
+		{params: url.Values{"email": {"mega@giantswarm.io"}, "limit": {"1000"}, "offset": {"-1"}}, wantIds: []int64{7161253}},
+		{params: url.Values{"email": {"nobody@giantswarm.io"}}, wantIds: []int64{}},
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	for testCaseNumber, testCase := range testCases {
+
+		employees, err := personio.GetEmployeesWithParams(testCase.params)
+		if err != nil {
+			t.Errorf("[%d] Failed to query employees: %s", testCaseNumber, err)
+			continue
+		}
+		if employees == nil {
+			t.Errorf("[%d] Expected non-nil employees", testCaseNumber)
+		}
+
+		if len(employees) != len(testCase.wantIds) {
+			t.Errorf("[%d] Expected %d employees, got %d", testCaseNumber, len(testCase.wantIds), len(employees))
+			continue
+		}
+
+		for i, wantId := range testCase.wantIds {
+			id := employees[i].GetIntAttribute("id")
+			if id == nil || *id != wantId {
+				t.Errorf("[%d] Expected employee %d, got %v", testCaseNumber, wantId, id)
+			}
+		}
+	}
+}
+
+func TestClient_EmptyTimeOffs(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	// no time-off lies in 2030
+	start, end := makeTime("2030-01-01T00:00:00Z"), makeTime("2030-01-31T00:00:00Z")
+
+	timeOffs, err := personio.GetTimeOffs(&start, &end, 0, intMax)
+	if err != nil {
+		t.Fatalf("Failed to query time-offs: %s", err)
+	}
+	if timeOffs == nil || len(timeOffs) != 0 {
+		t.Errorf("Expected empty non-nil time-offs, got %v", timeOffs)
+	}
+
+	timeOffs, err = personio.GetTimeOffsMapped(start, end)
+	if err != nil {
+		t.Fatalf("Failed to query mapped time-offs: %s", err)
+	}
+	if timeOffs == nil || len(timeOffs) != 0 {
+		t.Errorf("Expected empty non-nil mapped time-offs, got %v", timeOffs)
+	}
+}
+
+func TestAttributeContainer_RawValue(t *testing.T) {
+
+	var employee Employee
+	err := json.Unmarshal([]byte(`{"type": "Employee", "attributes": {
+		"badge": {"label": "Badge", "value": {"level": 3, "colors": ["gold"]}, "type": "badge"},
+		"nickname": {"label": "Nickname", "value": null, "type": "standard"}
+	}}`), &employee)
+	if err != nil {
+		t.Fatalf("Failed to decode employee: %s", err)
+	}
+
+	// typed getters don't know the badge type
+	if value := employee.GetMapAttribute("badge"); len(value) != 0 {
+		t.Errorf("Expected no typed value of unknown type, got %v", value)
+	}
+
+	value, ok := employee.RawValue("badge")
+	want := map[string]interface{}{"level": 3.0, "colors": []interface{}{"gold"}}
+	if !ok || !reflect.DeepEqual(value, want) {
+		t.Errorf("Expected raw value %v, got %v (%t)", want, value, ok)
+	}
+	if value, ok := employee.RawValue("nickname"); !ok || value != nil {
+		t.Errorf("Expected existing null value, got %v (%t)", value, ok)
+	}
+	if value, ok := employee.RawValue("missing"); ok || value != nil {
+		t.Errorf("Expected missing value, got %v (%t)", value, ok)
+	}
+
+	var container *AttributeContainer
+	if _, ok := container.RawValue("badge"); ok {
+		t.Errorf("Expected missing value from nil container")
+	}
+}
+
+// TestClient_TimeOffDateBoundaries documents the agreed boundary semantics of the start and end dates:
+// both are inclusive calendar days in the location of the passed time.Time, time-offs are matched by their own calendar days
+func TestClient_TimeOffDateBoundaries(t *testing.T) {
+
+	cest := time.FixedZone("CEST", 2*60*60)
+	date := func(year int, month time.Month, day int, loc *time.Location) *time.Time {
+		value := time.Date(year, month, day, 0, 0, 0, 0, loc)
+		return &value
+	}
+
+	cestMidnightInUtc := date(2022, 9, 5, cest).In(time.UTC)
+
+	// time-off 125814620 spans 2022-09-05 to 2022-09-09
+	testCases := []struct {
+		start *time.Time
+		end   *time.Time
+		want  bool
+	}{
+		{start: nil, end: date(2022, 9, 5, time.UTC), want: true},
+		{start: nil, end: date(2022, 9, 4, time.UTC), want: false},
+		{start: date(2022, 9, 9, time.UTC), end: nil, want: true},
+		{start: date(2022, 9, 10, time.UTC), end: nil, want: false},
+		{start: date(2022, 9, 6, time.UTC), end: date(2022, 9, 6, time.UTC), want: true},
+		// 2022-09-05 00:00 CEST is still 2022-09-04 in UTC
+		{start: nil, end: &cestMidnightInUtc, want: false},
+		{start: nil, end: date(2022, 9, 5, cest), want: true},
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	for testCaseNumber, testCase := range testCases {
+
+		timeOffs, err := personio.GetTimeOffs(testCase.start, testCase.end, 0, intMax)
+		if err != nil {
+			t.Errorf("[%d] Failed to query time-offs: %s", testCaseNumber, err)
+			continue
+		}
+
+		found := false
+		for _, timeOff := range timeOffs {
+			if timeOff.Id == 125814620 {
+				found = true
+			}
+		}
+		if found != testCase.want {
+			t.Errorf("[%d] Expected time-off 125814620 returned %t, got %t", testCaseNumber, testCase.want, found)
+		}
+	}
+}
+
+func TestAttribute_GuessValue(t *testing.T) {
+
+	testCases := []struct {
+		attribute *Attribute
+		want      interface{}
+	}{
+		{attribute: nil, want: nil},
+		{attribute: &Attribute{Type: "integer", Value: 6205887.0}, want: int64(6205887)},
+		{attribute: &Attribute{Type: "decimal", Value: 7042.42}, want: 7042.42},
+		{attribute: &Attribute{Type: "standard", Value: "42"}, want: int64(42)},
+		{attribute: &Attribute{Type: "standard", Value: "0.5"}, want: 0.5},
+		{attribute: &Attribute{Type: "standard", Value: "-12"}, want: int64(-12)},
+		{attribute: &Attribute{Type: "standard", Value: "0.25"}, want: 0.25},
+		{attribute: &Attribute{Type: "standard", Value: "01067"}, want: "01067"},
+		{attribute: &Attribute{Type: "standard", Value: "Nan"}, want: "Nan"},
+		{attribute: &Attribute{Type: "standard", Value: "Inf"}, want: "Inf"},
+		{attribute: &Attribute{Type: "standard", Value: "infinity"}, want: "infinity"},
+		{attribute: &Attribute{Type: "standard", Value: "T"}, want: "T"},
+		{attribute: &Attribute{Type: "standard", Value: "f"}, want: "f"},
+		{attribute: &Attribute{Type: "date", Value: "2022-01-12T00:00:00+01:00"}, want: makeTime("2022-01-12T00:00:00+01:00")},
+		{attribute: &Attribute{Type: "standard", Value: "2022-01-12"}, want: makeTime("2022-01-12T00:00:00Z")},
+		{attribute: &Attribute{Type: "standard", Value: "true"}, want: true},
+		{attribute: &Attribute{Type: "standard", Value: false}, want: false},
+		{attribute: &Attribute{Type: "tags", Value: "Go,Kubernetes"}, want: []string{"Go", "Kubernetes"}},
+		{attribute: &Attribute{Type: "standard", Value: []interface{}{map[string]interface{}{"id": 31001.0, "name": "Go"}}}, want: []string{"Go"}},
+		{attribute: &Attribute{Type: "standard", Value: "El Gonzo, Jr."}, want: "El Gonzo, Jr."},
+		{attribute: &Attribute{Type: "standard", Value: map[string]interface{}{"type": "Office"}}, want: map[string]interface{}{"type": "Office"}},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		got := testCase.attribute.GuessValue()
+		if wantTime, ok := testCase.want.(time.Time); ok {
+			gotTime, ok := got.(time.Time)
+			if !ok || !gotTime.Equal(wantTime) {
+				t.Errorf("[%d] Expected %v, got %#v", testCaseNumber, wantTime, got)
+			}
+			continue
+		}
+		if fmt.Sprintf("%#v", got) != fmt.Sprintf("%#v", testCase.want) {
+			t.Errorf("[%d] Expected %#v, got %#v", testCaseNumber, testCase.want, got)
+		}
+	}
+}
+
+// failingTransport fails the next failures requests other than authentication before they reach the server
+type failingTransport struct {
+	failures int
+}
+
+func (f *failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Path != "/auth" && f.failures > 0 {
+		f.failures--
+		return nil, errors.New("connection reset")
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClient_TokenPreservedOnTransportError(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	transport := &failingTransport{failures: 1}
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	_, err = personio.GetEmployee(6205887)
+	if err == nil {
+		t.Errorf("Expected transport error, got none")
+		return
+	}
+
+	if personio.secret.AccessToken != "ghi" {
+		t.Errorf("Expected unused access token ghi to be preserved, got %q", personio.secret.AccessToken)
+	}
+
+	_, err = personio.GetEmployee(6205887)
+	if err != nil {
+		t.Errorf("Failed to query employee after transport error: %s", err)
+		return
+	}
+
+	if server.mock.authCount != 1 {
+		t.Errorf("Expected 1 authentication, got %d", server.mock.authCount)
+	}
+}
+
+// cancellingTransport calls cancel after the first response of a request to path
+type cancellingTransport struct {
+	path     string
+	cancel   context.CancelFunc
+	requests int
+}
+
+func (c *cancellingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Path != c.path {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	c.requests++
+	response, err := http.DefaultTransport.RoundTrip(req)
+	c.cancel()
+	return response, err
+}
+
+func TestClient_GetEmployeesContext(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	// one employee per page
+	server.mock.maxPageSize = 1
+
+	transport := &cancellingTransport{path: "/company/employees", cancel: func() {}}
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	employees, err := personio.GetEmployeesContext(context.Background())
+	if err != nil || len(employees) != 2 {
+		t.Fatalf("Expected 2 employees without cancellation, got %d and %v", len(employees), err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	transport.requests, transport.cancel = 0, cancel
+	employees, err = personio.GetEmployeesContext(ctx)
+	if !errors.Is(err, context.Canceled) || employees != nil {
+		t.Errorf("Expected context.Canceled, got %v and %v", employees, err)
+	}
+	if transport.requests != 1 {
+		t.Errorf("Expected fetching to stop after the first page, got %d requests", transport.requests)
+	}
+}
+
+func TestCredentialsFromMap(t *testing.T) {
+
+	testCases := []struct {
+		m       map[string]interface{}
+		want    Credentials
+		wantErr bool
+	}{
+		{m: map[string]interface{}{"clientId": "abc", "clientSecret": "def"}, want: Credentials{ClientId: "abc", ClientSecret: "def"}},
+		{m: map[string]interface{}{"client_id": "abc", "client_secret": "def", "access_token": "ghi"}, want: Credentials{ClientId: "abc", ClientSecret: "def", AccessToken: "ghi"}},
+		{m: map[string]interface{}{"client_id": "abc"}, wantErr: true},
+		{m: map[string]interface{}{"client_id": 42, "client_secret": "def"}, wantErr: true},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		got, err := CredentialsFromMap(testCase.m)
+		if (err != nil) != testCase.wantErr {
+			t.Errorf("[%d] Expected error %t, got %v", testCaseNumber, testCase.wantErr, err)
+			continue
+		}
+		if got != testCase.want {
+			t.Errorf("[%d] Expected %+v, got %+v", testCaseNumber, testCase.want, got)
+		}
+	}
+
+	// JSON consumers keep camelCase keys
+	var credentials Credentials
+	err := json.Unmarshal([]byte(`{"clientId": "abc", "clientSecret": "def"}`), &credentials)
+	if err != nil || credentials.ClientId != "abc" || credentials.ClientSecret != "def" {
+		t.Errorf("Expected camelCase JSON credentials, got %+v (%v)", credentials, err)
+	}
+}
@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrNotPersonioAPI is wrapped by errors of Probe if the base URL responds, but not like the Personio API
+var ErrNotPersonioAPI = errors.New("not the personio api")
+
+// probeBodyLimit is the maximum number of bytes of the probe response read
+const probeBodyLimit = 1 << 20
+
+// Probe checks that baseUrl (DefaultBaseUrl if empty) serves the Personio API, e.g. to catch typos in configured URLs
+//
+// It authenticates with empty credentials, which is expected to fail with Personio's JSON envelope
+// ({"success": false, "error": {...}}). Other responses, e.g. HTML pages or JSON of a different shape, fail with an
+// error wrapping ErrNotPersonioAPI, transport errors are returned as is.
+func Probe(ctx context.Context, baseUrl string) error {
+
+	if baseUrl == "" {
+		baseUrl = DefaultBaseUrl
+	}
+
+	form := url.Values{}
+	form.Add("client_id", "")
+	form.Add("client_secret", "")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseUrl+"/auth", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", DefaultAuthContentType)
+	req.Header.Set("Accept", "application/json")
+
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(response.Body)
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, probeBodyLimit))
+	if err != nil {
+		return err
+	}
+
+	var envelope map[string]json.RawMessage
+	err = json.Unmarshal(body, &envelope)
+	if err != nil {
+		return fmt.Errorf("%w: %s returned %s without JSON body (Content-Type %q)", ErrNotPersonioAPI, req.URL, response.Status, response.Header.Get("Content-Type"))
+	}
+
+	var success bool
+	err = json.Unmarshal(envelope["success"], &success)
+	if err != nil {
+		return fmt.Errorf("%w: %s returned %s without \"success\" flag", ErrNotPersonioAPI, req.URL, response.Status)
+	}
+
+	if _, ok := envelope["error"]; !success && !ok {
+		return fmt.Errorf("%w: %s returned %s failure without \"error\" object", ErrNotPersonioAPI, req.URL, response.Status)
+	}
+
+	return nil
+}
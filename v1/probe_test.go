@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProbe(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/html/auth":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = io.WriteString(w, "<html><body>Welcome</body></html>")
+		case "/json/auth":
+			_, _ = io.WriteString(w, "{\"status\": \"ok\"}")
+		case "/failure/auth":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, "{\"success\": false}")
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer other.Close()
+
+	testCases := []struct {
+		baseUrl         string
+		wantErr         bool
+		wantNotPersonio bool
+	}{
+		{baseUrl: fmt.Sprintf("http://localhost:%d", server.port)},
+		{baseUrl: other.URL + "/html", wantErr: true, wantNotPersonio: true},
+		{baseUrl: other.URL + "/json", wantErr: true, wantNotPersonio: true},
+		{baseUrl: other.URL + "/failure", wantErr: true, wantNotPersonio: true},
+		{baseUrl: other.URL + "/missing", wantErr: true, wantNotPersonio: true},
+		// nothing listening
+		{baseUrl: "http://localhost:1", wantErr: true},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		err := Probe(context.TODO(), testCase.baseUrl)
+		if (err != nil) != testCase.wantErr {
+			t.Errorf("[%d] Expected error %t, got %v", testCaseNumber, testCase.wantErr, err)
+			continue
+		}
+		if errors.Is(err, ErrNotPersonioAPI) != testCase.wantNotPersonio {
+			t.Errorf("[%d] Expected ErrNotPersonioAPI %t, got %v", testCaseNumber, testCase.wantNotPersonio, err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+)
+
+// ErrUnexpectedContentType is returned if Personio responds with a different content type than requested
+var ErrUnexpectedContentType = errors.New("unexpected personio response content type")
+
+// GetCustomReportCSV returns the custom report with the specified ID as raw CSV
+//
+// Personio reports errors of the CSV endpoint as JSON envelope, which is detected by the response's Content-Type
+// and returned as error like for other endpoints. Responses without Content-Type are taken as CSV.
+func (personio *Client) GetCustomReportCSV(reportId string) ([]byte, error) {
+
+	req, err := http.NewRequest(http.MethodGet, personio.baseUrl+"/company/custom-reports/reports/"+url.PathEscape(reportId), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "text/csv")
+
+	body, header, err := personio.doRequest(req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv", "":
+		return body, nil
+	case "application/json":
+		var result resultBody
+		if json.Unmarshal(body, &result) == nil && !result.Success {
+			return nil, personio.resultError(result, req)
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnexpectedContentType, header.Get("Content-Type"))
+}
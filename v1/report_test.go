@@ -0,0 +1,47 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestClient_GetCustomReportCSV(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	csv, err := personio.GetCustomReportCSV("headcount")
+	if err != nil {
+		t.Fatalf("Failed to fetch report: %s", err)
+	}
+	if want := "Office,Headcount\nBerlin,1\nCologne,1\n"; string(csv) != want {
+		t.Errorf("Expected CSV %q, got %q", want, csv)
+	}
+
+	_, err = personio.GetCustomReportCSV("missing")
+	if err == nil || !strings.Contains(err.Error(), "report not found") {
+		t.Errorf("Expected Personio's JSON error, got %v", err)
+	}
+
+	_, err = personio.GetCustomReportCSV("legacy")
+	if !errors.Is(err, ErrUnexpectedContentType) {
+		t.Errorf("Expected ErrUnexpectedContentType, got %v", err)
+	}
+}
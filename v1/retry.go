@@ -0,0 +1,144 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	util "github.com/giantswarm/personio-go"
+)
+
+// DefaultMaxRetryWait is the default maximum duration to wait before retrying a request
+const DefaultMaxRetryWait = time.Minute
+
+// defaultRetryBackoff is the initial wait before retrying a request without Retry-After header
+const defaultRetryBackoff = 500 * time.Millisecond
+
+// ErrServiceUnavailable is returned if Personio is unavailable for longer than the maximum retry wait (e.g. maintenance)
+var ErrServiceUnavailable = errors.New("personio service unavailable")
+
+// WithRetries sets how often requests rejected with 429 Too Many Requests or 503 Service Unavailable are retried (default 0)
+func WithRetries(maxRetries int) Option {
+	return func(personio *Client) {
+		personio.maxRetries = maxRetries
+	}
+}
+
+// WithMaxRetryWait caps the wait before a retry, a longer Retry-After of a 503 response fails with ErrServiceUnavailable
+func WithMaxRetryWait(maxWait time.Duration) Option {
+	return func(personio *Client) {
+		personio.maxRetryWait = maxWait
+	}
+}
+
+// WithTotalTimeout bounds each operation including authentication, all retries and their waits to d
+//
+// Operations exceeding d fail with context.DeadlineExceeded, independent of the http.Client's per-attempt timeout.
+// Operations issuing several requests (e.g. fetching multiple pages or CreateTimeOffs) share d across all of them.
+func WithTotalTimeout(d time.Duration) Option {
+	return func(personio *Client) {
+		personio.totalTimeout = d
+	}
+}
+
+// totalTimeoutKey marks contexts already bound to the total timeout of their operation
+type totalTimeoutKey struct{}
+
+// withTotalTimeout returns ctx bound to the total timeout configured via WithTotalTimeout
+//
+// Contexts that can't be cancelled are tied to the Client's context via withClientContext. Contexts already bound to
+// a total timeout are returned unchanged, so all requests of an operation share the deadline derived once at its start.
+func (personio *Client) withTotalTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	release := func() {}
+	if ctx.Done() == nil {
+		ctx, release = personio.withClientContext(ctx)
+	}
+	if personio.totalTimeout <= 0 || ctx.Value(totalTimeoutKey{}) != nil {
+		return ctx, release
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, personio.totalTimeout)
+	return context.WithValue(ctx, totalTimeoutKey{}, true), func() {
+		cancel()
+		release()
+	}
+}
+
+// clientContext is a caller's context falling back to the values of the Client's context
+type clientContext struct {
+	context.Context
+	client context.Context
+}
+
+// Value returns the caller's value for key or, if there is none, the one of the Client's context
+func (c clientContext) Value(key interface{}) interface{} {
+	if value := c.Context.Value(key); value != nil {
+		return value
+	}
+	return c.client.Value(key)
+}
+
+// withClientContext returns ctx cancelled once the Client's context is done
+//
+// The values of ctx (e.g. a request ID set via ContextWithRequestId) take precedence over the ones of the Client's context.
+func (personio *Client) withClientContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.(clientContext); ok || personio.ctx == nil || ctx == personio.ctx {
+		return ctx, func() {}
+	}
+
+	merged := clientContext{Context: ctx, client: personio.ctx}
+	if personio.ctx.Done() == nil {
+		return merged, func() {}
+	}
+
+	cancellable, cancel := context.WithCancel(merged)
+	go func() {
+		select {
+		case <-personio.ctx.Done():
+			cancel()
+		case <-cancellable.Done():
+		}
+	}()
+	return cancellable, cancel
+}
+
+// isRetryable returns whether a request failed with the specified status code may succeed later
+func isRetryable(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable
+}
+
+// retryWait returns the duration to wait before the specified retry based on the response header or exponential backoff
+func (personio *Client) retryWait(statusErr StatusError, header http.Header, retry int) (time.Duration, error) {
+
+	if wait, ok := util.ParseRetryAfter(header.Get("Retry-After"), personio.clock()); ok {
+		if wait > personio.maxRetryWait {
+			if statusErr.Code == http.StatusServiceUnavailable {
+				statusErr.Err = fmt.Errorf("%w: retry after %s exceeds maximum wait of %s", ErrServiceUnavailable, wait, personio.maxRetryWait)
+			}
+			return 0, statusErr
+		}
+		return wait, nil
+	}
+
+	wait := personio.retryBackoff << retry
+	if wait > personio.maxRetryWait || wait <= 0 {
+		wait = personio.maxRetryWait
+	}
+	return wait, nil
+}
+
+// sleep waits for the specified duration or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
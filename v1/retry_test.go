@@ -0,0 +1,213 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type retryTestCase struct {
+	maxRetries      int
+	unavailable     []string
+	wantUnavailable bool
+	wantStatus      int
+}
+
+func TestClient_RetryServiceUnavailable(t *testing.T) {
+
+	retryCases := []retryTestCase{
+		{maxRetries: 0, unavailable: []string{"0"}, wantStatus: http.StatusServiceUnavailable},
+		{maxRetries: 2, unavailable: []string{"0", "1"}},
+		{maxRetries: 2, unavailable: []string{"", ""}},
+		{maxRetries: 2, unavailable: []string{"0", "0", "0"}, wantStatus: http.StatusServiceUnavailable},
+		{maxRetries: 2, unavailable: []string{"600"}, wantUnavailable: true, wantStatus: http.StatusServiceUnavailable},
+		// HTTP-dates relative to the client's clock at 2022-09-05T12:00:00Z
+		{maxRetries: 2, unavailable: []string{"Mon, 05 Sep 2022 12:00:01 GMT", "Mon, 05 Sep 2022 11:00:00 GMT"}},
+		{maxRetries: 2, unavailable: []string{"Mon, 05 Sep 2022 13:00:00 GMT"}, wantUnavailable: true, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	for testNumber, testCase := range retryCases {
+		personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+		personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithRetries(testCase.maxRetries), WithMaxRetryWait(5*time.Second))
+		if err != nil {
+			t.Errorf("[%d] Failed to create Personio API v1 client: %s", testNumber, err)
+			continue
+		}
+		personio.retryBackoff = time.Millisecond
+		personio.clock = func() time.Time { return makeTime("2022-09-05T12:00:00Z") }
+
+		server.mock.lastToken = ""
+		server.mock.unavailable = testCase.unavailable
+		started := time.Now()
+		_, err = personio.GetEmployee(6205887)
+
+		if time.Since(started) > 3*time.Second {
+			t.Errorf("[%d] Expected capped wait, took %s", testNumber, time.Since(started))
+		}
+
+		if testCase.wantStatus == 0 {
+			if err != nil {
+				t.Errorf("[%d] Expected retries to succeed, got %s", testNumber, err)
+			}
+			continue
+		}
+
+		var statusErr StatusError
+		if !errors.As(err, &statusErr) || statusErr.Code != testCase.wantStatus {
+			t.Errorf("[%d] Expected error code %d, got %v", testNumber, testCase.wantStatus, err)
+		}
+		if errors.Is(err, ErrServiceUnavailable) != testCase.wantUnavailable {
+			t.Errorf("[%d] Expected ErrServiceUnavailable %t, got %v", testNumber, testCase.wantUnavailable, err)
+		}
+	}
+}
+
+func TestClient_WithTotalTimeout(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithRetries(5), WithTotalTimeout(200*time.Millisecond))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	// each retry waits a second, exceeding the budget during the first wait
+	server.mock.unavailable = []string{"1", "1", "1"}
+	started := time.Now()
+	_, err = personio.GetEmployee(6205887)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("Expected the budget to end the retries, took %s", elapsed)
+	}
+
+	// the budget applies per operation
+	server.mock.unavailable = nil
+	_, err = personio.GetEmployee(6205887)
+	if err != nil {
+		t.Errorf("Expected the next operation to succeed, got %s", err)
+	}
+}
+
+// slowTransport delays every request to path by delay before sending it
+type slowTransport struct {
+	path     string
+	delay    time.Duration
+	requests int
+}
+
+func (s *slowTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Path == s.path {
+		s.requests++
+		select {
+		case <-time.After(s.delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClient_WithTotalTimeoutPages(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	// one of the 7 attendances per page, each page fits the budget but all of them don't
+	server.mock.maxPageSize = 1
+
+	transport := &slowTransport{path: "/company/attendances", delay: 50 * time.Millisecond}
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithHTTPClient(&http.Client{Transport: transport}), WithTotalTimeout(175*time.Millisecond))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	_, err = personio.GetAttendances(nil, nil, nil, 0, intMax)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if transport.requests > 4 {
+		t.Errorf("Expected the budget to end paging after 4 pages, got %d requests", transport.requests)
+	}
+
+	// a single page stays within the budget
+	transport.requests = 0
+	attendances, err := personio.GetAttendances(nil, nil, nil, 0, 1)
+	if err != nil || len(attendances) != 1 {
+		t.Errorf("Expected 1 attendance, got %d and %v", len(attendances), err)
+	}
+}
+
+func TestClient_WithTotalTimeoutContextValues(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	clientCtx, cancel := context.WithCancel(ContextWithRequestId(context.Background(), "client"))
+	defer cancel()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(clientCtx, fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithTotalTimeout(time.Second))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	// the caller's request ID wins over the client's, which remains the fallback
+	_, err = personio.GetEmployeesContext(ContextWithRequestId(context.Background(), "caller"))
+	if err != nil || server.mock.lastRequestId != "caller" {
+		t.Errorf("Expected request ID caller, got %q (%v)", server.mock.lastRequestId, err)
+	}
+	_, err = personio.GetEmployeesContext(context.Background())
+	if err != nil || server.mock.lastRequestId != "client" {
+		t.Errorf("Expected request ID client, got %q (%v)", server.mock.lastRequestId, err)
+	}
+
+	// cancelling the client's context still ends operations on contexts that can't be cancelled
+	cancel()
+	_, err = personio.GetEmployeesContext(ContextWithRequestId(context.Background(), "caller"))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
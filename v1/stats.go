@@ -0,0 +1,33 @@
+package v1
+
+import "sync/atomic"
+
+// ClientStats are counters of a Client's traffic since its creation, e.g. to diagnose excessive authentication
+type ClientStats struct {
+	// Requests is the number of HTTP requests sent, including authentication and retries
+	Requests int64
+	// AuthCalls is the number of authentication requests sent
+	AuthCalls int64
+	// Retries is the number of requests repeated after a rate limit or temporary unavailability
+	Retries int64
+	// Unauthorized is the number of responses with status 401 Unauthorized
+	Unauthorized int64
+}
+
+// clientStats are the counters behind ClientStats, allocated separately to keep them 64-bit aligned for atomic access
+type clientStats struct {
+	requests     int64
+	authCalls    int64
+	retries      int64
+	unauthorized int64
+}
+
+// Stats returns a snapshot of the Client's counters, safe to call concurrently with requests
+func (personio *Client) Stats() ClientStats {
+	return ClientStats{
+		Requests:     atomic.LoadInt64(&personio.stats.requests),
+		AuthCalls:    atomic.LoadInt64(&personio.stats.authCalls),
+		Retries:      atomic.LoadInt64(&personio.stats.retries),
+		Unauthorized: atomic.LoadInt64(&personio.stats.unauthorized),
+	}
+}
@@ -0,0 +1,62 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestClient_Stats(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithRetries(1))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	if stats := personio.Stats(); stats != (ClientStats{}) {
+		t.Errorf("Expected zero stats of new client, got %+v", stats)
+	}
+
+	// rotated tokens are reused, so only the first request authenticates
+	for i := 0; i < 2; i++ {
+		_, err = personio.GetEmployee(6205887)
+		if err != nil {
+			t.Fatalf("Failed to query employee: %s", err)
+		}
+	}
+	if stats, want := personio.Stats(), (ClientStats{Requests: 3, AuthCalls: 1}); stats != want {
+		t.Errorf("Expected %+v, got %+v", want, stats)
+	}
+
+	// the rejected request consumes its token without rotating it, so the retry authenticates
+	server.mock.unavailable = []string{"0"}
+	_, err = personio.GetEmployee(6205887)
+	if err != nil {
+		t.Fatalf("Failed to query employee: %s", err)
+	}
+	if stats, want := personio.Stats(), (ClientStats{Requests: 6, AuthCalls: 2, Retries: 1}); stats != want {
+		t.Errorf("Expected %+v, got %+v", want, stats)
+	}
+
+	// a rejected token is replaced once by re-authenticating
+	server.mock.rejectTokens = true
+	_, err = personio.GetEmployee(6205887)
+	if err == nil {
+		t.Fatalf("Expected rejected token to fail")
+	}
+	if stats, want := personio.Stats(), (ClientStats{Requests: 9, AuthCalls: 3, Retries: 1, Unauthorized: 2}); stats != want {
+		t.Errorf("Expected %+v, got %+v", want, stats)
+	}
+}
@@ -0,0 +1,636 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	util "github.com/giantswarm/personio-go"
+)
+
+// EmployeeID returns the ID of the time-off's employee as embedded in the time-off or nil if it's missing
+func (t *TimeOff) EmployeeID() *int64 {
+	if t == nil {
+		return nil
+	}
+	return t.Employee.GetIntAttribute("id")
+}
+
+// EmployeeEmail returns the email of the time-off's employee as embedded in the time-off or nil if it's missing
+func (t *TimeOff) EmployeeEmail() *string {
+	if t == nil {
+		return nil
+	}
+	return t.Employee.GetStringAttribute("email")
+}
+
+// groupTimeOffsByEmployee groups the time-offs by their employee's ID and returns the number of time-offs without one
+func groupTimeOffsByEmployee(timeOffs []*TimeOff) (map[int64][]*TimeOff, int) {
+	grouped := make(map[int64][]*TimeOff)
+	skipped := 0
+	for _, timeOff := range timeOffs {
+		employeeId := timeOff.EmployeeID()
+		if employeeId == nil {
+			skipped++
+			continue
+		}
+		grouped[*employeeId] = append(grouped[*employeeId], timeOff)
+	}
+	return grouped, skipped
+}
+
+// GetTimeOffsGroupedByEmployee returns the time-offs matching the specified start and end dates grouped by employee ID
+//
+// Time-offs without employee ID are skipped, their count is reported to the Logger
+func (personio *Client) GetTimeOffsGroupedByEmployee(start *time.Time, end *time.Time) (map[int64][]*TimeOff, error) {
+
+	timeOffs, err := personio.GetTimeOffs(start, end, 0, intMax)
+	if err != nil {
+		return nil, err
+	}
+
+	grouped, skipped := groupTimeOffsByEmployee(timeOffs)
+	if skipped > 0 {
+		personio.logf("personio: skipped %d time-offs without employee id", skipped)
+	}
+
+	return grouped, nil
+}
+
+// latestTimeOffs returns each employee's time-off with the latest EndDate, ties broken by the latest UpdatedAt
+func latestTimeOffs(grouped map[int64][]*TimeOff) map[int64]*TimeOff {
+	latest := make(map[int64]*TimeOff, len(grouped))
+	for employeeId, timeOffs := range grouped {
+		for _, timeOff := range timeOffs {
+			current, ok := latest[employeeId]
+			if !ok || timeOff.EndDate.After(current.EndDate) ||
+				(timeOff.EndDate.Equal(current.EndDate) && timeOff.UpdatedAt.After(current.UpdatedAt)) {
+				latest[employeeId] = timeOff
+			}
+		}
+	}
+	return latest
+}
+
+// GetLatestTimeOffPerEmployee returns the time-off with the latest end date per employee ID among those matching start and end
+//
+// Ties are broken by the latest update. Employees without time-offs in the range are absent from the map,
+// time-offs without employee ID are skipped and their count is reported to the Logger.
+func (personio *Client) GetLatestTimeOffPerEmployee(start *time.Time, end *time.Time) (map[int64]*TimeOff, error) {
+
+	grouped, err := personio.GetTimeOffsGroupedByEmployee(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return latestTimeOffs(grouped), nil
+}
+
+// IsValidHalfDay returns whether the half-day flags are consistent with the time-off's date range
+//
+// Valid combinations are:
+//   - single day: no flag (full day), or exactly one of HalfDayStart and HalfDayEnd (half day)
+//   - multiple days: any combination, the flags apply to the first and last day respectively
+//
+// Time-offs ending before they start are never valid.
+func (t *TimeOff) IsValidHalfDay() bool {
+	startYear, startMonth, startDay := t.StartDate.Date()
+	endYear, endMonth, endDay := t.EndDate.Date()
+	start := time.Date(startYear, startMonth, startDay, 0, 0, 0, 0, time.UTC)
+	end := time.Date(endYear, endMonth, endDay, 0, 0, 0, 0, time.UTC)
+
+	switch {
+	case end.Before(start):
+		return false
+	case end.Equal(start):
+		return !bool(t.HalfDayStart && t.HalfDayEnd)
+	default:
+		return true
+	}
+}
+
+// CreateTimeOffs creates the specified time-offs with up to concurrency requests in parallel
+//
+// Results and errors are reported per request at the request's index, each request is retried as configured for the Client
+// and all of them share the total timeout set via WithTotalTimeout
+func (personio *Client) CreateTimeOffs(reqs []CreateTimeOffRequest, concurrency int) ([]*TimeOff, []error) {
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ctx, cancel := personio.withTotalTimeout(context.Background())
+	defer cancel()
+
+	timeOffs := make([]*TimeOff, len(reqs))
+	errs := make([]error, len(reqs))
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency && worker < len(reqs); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				timeOffs[i], _, errs[i] = personio.createTimeOff(ctx, reqs[i])
+			}
+		}()
+	}
+
+	for i := range reqs {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	return timeOffs, errs
+}
+
+// OverlapFraction returns the overlap of two time-offs relative to the shorter one's duration, 0 if they are disjoint
+//
+// Time-offs are taken as whole days from the start of StartDate to the end of EndDate, half-day flags are ignored
+func OverlapFraction(a *TimeOff, b *TimeOff) float64 {
+	if a == nil || b == nil {
+		return 0
+	}
+
+	const day = 24 * time.Hour
+	aEnd, bEnd := a.EndDate.Add(day), b.EndDate.Add(day)
+
+	overlap := util.GetTimeIntersection(a.StartDate, aEnd, b.StartDate, bEnd)
+	if overlap <= 0 {
+		return 0
+	}
+
+	shorter := aEnd.Sub(a.StartDate)
+	if bDuration := bEnd.Sub(b.StartDate); bDuration < shorter {
+		shorter = bDuration
+	}
+	if shorter <= 0 {
+		return 0
+	}
+
+	return float64(overlap) / float64(shorter)
+}
+
+// ErrInvalidStatusTransition is returned if a time-off's current status does not allow the requested transition
+var ErrInvalidStatusTransition = errors.New("invalid time-off status transition")
+
+// timeOffStatusBody is the request body of PATCH /company/time-offs/{id}
+type timeOffStatusBody struct {
+	Status  string `json:"status"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// GetTimeOff returns the time-off with the specified ID
+func (personio *Client) GetTimeOff(id int64) (*TimeOff, error) {
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/company/time-offs/%d", personio.baseUrl, id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := personio.doRequestJson(req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result timeOffResult
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	personio.applyAttributeConfig(&result.Data.Attributes.Employee)
+	return &result.Data.Attributes, nil
+}
+
+// transitionTimeOff changes the status of a pending time-off, failing with ErrInvalidStatusTransition otherwise
+func (personio *Client) transitionTimeOff(id int64, status string, comment string) error {
+
+	timeOff, err := personio.GetTimeOff(id)
+	if err != nil {
+		return err
+	}
+
+	if timeOff.Status != "pending" {
+		return fmt.Errorf("%w: time-off %d is %s, not pending", ErrInvalidStatusTransition, id, timeOff.Status)
+	}
+
+	requestBody, err := json.Marshal(timeOffStatusBody{Status: status, Comment: comment})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPatch, fmt.Sprintf("%s/company/time-offs/%d", personio.baseUrl, id), bytes.NewReader(requestBody))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = personio.doRequestJson(req, true)
+	return err
+}
+
+// ApproveTimeOff approves the pending time-off with the specified ID
+//
+// Personio offers no dedicated approval endpoint, so the status is changed via PATCH /company/time-offs/{id}
+func (personio *Client) ApproveTimeOff(id int64) error {
+	return personio.transitionTimeOff(id, "approved", "")
+}
+
+// RejectTimeOff rejects the pending time-off with the specified ID, stating reason as comment
+//
+// Personio offers no dedicated rejection endpoint, so the status is changed via PATCH /company/time-offs/{id}
+func (personio *Client) RejectTimeOff(id int64, reason string) error {
+	return personio.transitionTimeOff(id, "rejected", reason)
+}
+
+// interval returns the time span the time-off covers with calendar days evaluated in loc (the dates' own location if nil)
+//
+// Half days cover the afternoon of the first day (HalfDayStart) and the morning of the last day (HalfDayEnd);
+// single-day time-offs with one flag cover the morning (HalfDayStart) or the afternoon (HalfDayEnd).
+func (t *TimeOff) interval(loc *time.Location) (time.Time, time.Time) {
+
+	if loc == nil {
+		loc = t.StartDate.Location()
+	}
+	startYear, startMonth, startDay := t.StartDate.Date()
+	endYear, endMonth, endDay := t.EndDate.Date()
+	start := time.Date(startYear, startMonth, startDay, 0, 0, 0, 0, loc)
+	end := time.Date(endYear, endMonth, endDay, 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+
+	halfDayStart, halfDayEnd := bool(t.HalfDayStart), bool(t.HalfDayEnd)
+	if startYear == endYear && startMonth == endMonth && startDay == endDay {
+		if halfDayStart && !halfDayEnd {
+			end = start.Add(12 * time.Hour)
+		} else if !halfDayStart && halfDayEnd {
+			start = start.Add(12 * time.Hour)
+		}
+		return start, end
+	}
+
+	if halfDayStart {
+		start = start.Add(12 * time.Hour)
+	}
+	if halfDayEnd {
+		end = end.Add(-12 * time.Hour)
+	}
+	return start, end
+}
+
+// TimeOffRangeMode selects which time-offs GetTimeOffsWithMode matches to a date range
+type TimeOffRangeMode int
+
+const (
+	// TimeOffRangeOverlap matches time-offs overlapping the range by at least one day, like GetTimeOffs
+	TimeOffRangeOverlap TimeOffRangeMode = iota
+	// TimeOffRangeStart matches time-offs starting within the range, regardless of when they end
+	TimeOffRangeStart
+)
+
+// GetTimeOffsWithMode returns the time-offs matching the specified start and end dates (inclusive, ignored if nil)
+// according to mode
+//
+// With TimeOffRangeStart a time-off from 2022-09-30 to 2022-10-04 belongs to September only, while it overlaps both
+// September and October. Personio only filters by overlap, so starts are filtered client-side on the overlapping
+// time-offs: offset and limit apply before this filter.
+func (personio *Client) GetTimeOffsWithMode(start *time.Time, end *time.Time, mode TimeOffRangeMode, offset int, limit int) ([]*TimeOff, error) {
+
+	timeOffs, err := personio.GetTimeOffs(start, end, offset, limit)
+	if err != nil || mode == TimeOffRangeOverlap {
+		return timeOffs, err
+	}
+
+	matched := make([]*TimeOff, 0, len(timeOffs))
+	for _, timeOff := range timeOffs {
+		day := util.FormatPersonioDate(timeOff.StartDate, nil)
+		if start != nil && day < util.FormatPersonioDate(*start, nil) {
+			continue
+		}
+		if end != nil && day > util.FormatPersonioDate(*end, nil) {
+			continue
+		}
+		matched = append(matched, timeOff)
+	}
+
+	return matched, nil
+}
+
+// GetTimeOffsOnDate returns the time-offs covering the calendar day of date in the location configured via WithLocation
+func (personio *Client) GetTimeOffsOnDate(date time.Time) ([]*TimeOff, error) {
+
+	if personio.location != nil {
+		date = date.In(personio.location)
+	}
+
+	timeOffs, err := personio.GetTimeOffs(&date, &date, 0, intMax)
+	if err != nil {
+		return nil, err
+	}
+
+	day := util.FormatPersonioDate(date, nil)
+	matched := make([]*TimeOff, 0, len(timeOffs))
+	for _, timeOff := range timeOffs {
+		if util.FormatPersonioDate(timeOff.StartDate, nil) <= day && util.FormatPersonioDate(timeOff.EndDate, nil) >= day {
+			matched = append(matched, timeOff)
+		}
+	}
+
+	return matched, nil
+}
+
+// GetCurrentTimeOffs returns the time-offs in progress right now, taking half days into account
+func (personio *Client) GetCurrentTimeOffs() ([]*TimeOff, error) {
+
+	now := personio.clock()
+	timeOffs, err := personio.GetTimeOffsOnDate(now)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make([]*TimeOff, 0, len(timeOffs))
+	for _, timeOff := range timeOffs {
+		start, end := timeOff.interval(personio.location)
+		if !now.Before(start) && now.Before(end) {
+			current = append(current, timeOff)
+		}
+	}
+
+	return current, nil
+}
+
+// Amount returns the time-off's duration and its unit ("days" or "hours")
+//
+// Hour-based time-offs report their effective duration, day-based ones fall back to DaysCount if no
+// effective duration is given
+func (t *TimeOff) Amount() (value float64, unit string) {
+	if t.MeasurementUnit == "hours" {
+		return t.EffectiveDuration, "hours"
+	}
+	if t.EffectiveDuration != 0 {
+		return t.EffectiveDuration, "days"
+	}
+	return t.DaysCount, "days"
+}
+
+// TimeOffDay is a single calendar day covered by a time-off, Fraction is 0.5 for half days and 1 otherwise
+type TimeOffDay struct {
+	Date     time.Time
+	Fraction float64
+}
+
+// ExpandTimeOffDays returns the calendar days the time-off covers from StartDate to EndDate (inclusive) as midnight UTC
+//
+// Calendar days are taken in the dates' own location, time-offs ending before they start cover no days. HalfDayStart
+// is assumed to make StartDate a half day (afternoon off) and HalfDayEnd to make EndDate a half day (morning off), a
+// single-day time-off with either flag is a half day.
+func ExpandTimeOffDays(t *TimeOff) []TimeOffDay {
+	if t == nil {
+		return nil
+	}
+
+	startYear, startMonth, startDay := t.StartDate.Date()
+	endYear, endMonth, endDay := t.EndDate.Date()
+	start := time.Date(startYear, startMonth, startDay, 0, 0, 0, 0, time.UTC)
+	end := time.Date(endYear, endMonth, endDay, 0, 0, 0, 0, time.UTC)
+
+	var days []TimeOffDay
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		fraction := 1.0
+		if (day.Equal(start) && bool(t.HalfDayStart)) || (day.Equal(end) && bool(t.HalfDayEnd)) {
+			fraction = 0.5
+		}
+		days = append(days, TimeOffDay{Date: day, Fraction: fraction})
+	}
+	return days
+}
+
+// GetMonthAbsenceCalendar returns the IDs of the employees off on each day of the specified month
+//
+// The map contains every day of the month as midnight UTC, days nobody is off map to an empty slice. IDs are sorted
+// and listed once per day even if an employee has several time-offs that day. Time-offs without employee ID are
+// skipped and their count is reported to the Logger.
+func (personio *Client) GetMonthAbsenceCalendar(year int, month time.Month) (map[time.Time][]int64, error) {
+
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1)
+
+	grouped, err := personio.GetTimeOffsGroupedByEmployee(&first, &last)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := make(map[time.Time][]int64, last.Day())
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		calendar[day] = []int64{}
+	}
+
+	for employeeId, timeOffs := range grouped {
+		for _, timeOff := range timeOffs {
+			for _, day := range ExpandTimeOffDays(timeOff) {
+				ids, ok := calendar[day.Date]
+				if !ok || (len(ids) > 0 && ids[len(ids)-1] == employeeId) {
+					continue
+				}
+				calendar[day.Date] = append(ids, employeeId)
+			}
+		}
+	}
+
+	for _, ids := range calendar {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	}
+
+	return calendar, nil
+}
+
+// WorkingDays returns the number of working days the time-off covers, counting half days as 0.5
+//
+// Days falling on weekends (Saturday and Sunday if nil) or on one of the holidays are not counted. Holidays are
+// compared as calendar days in their own location, days of the time-off in the location of its dates.
+func WorkingDays(t TimeOff, weekends []time.Weekday, holidays []time.Time) float64 {
+
+	if weekends == nil {
+		weekends = []time.Weekday{time.Saturday, time.Sunday}
+	}
+	weekend := make(map[time.Weekday]bool, len(weekends))
+	for _, day := range weekends {
+		weekend[day] = true
+	}
+
+	holiday := make(map[time.Time]bool, len(holidays))
+	for _, day := range holidays {
+		year, month, dayOfMonth := day.Date()
+		holiday[time.Date(year, month, dayOfMonth, 0, 0, 0, 0, time.UTC)] = true
+	}
+
+	workingDays := 0.0
+	for _, day := range ExpandTimeOffDays(&t) {
+		if !weekend[day.Date.Weekday()] && !holiday[day.Date] {
+			workingDays += day.Fraction
+		}
+	}
+
+	return workingDays
+}
+
+// DaysCountCheck is called with a time-off whose DaysCount disagrees with the working days computed by WorkingDays
+type DaysCountCheck func(timeOff *TimeOff, workingDays float64)
+
+// WithDaysCountCheck makes GetTimeOffs call check for each day-based time-off whose DaysCount deviates from its working
+// days (Monday to Friday, holidays not considered) by more than threshold, e.g. to report bad data during a sync
+//
+// Hour-based time-offs are not checked. The check only observes, time-offs are returned unchanged.
+func WithDaysCountCheck(threshold float64, check DaysCountCheck) Option {
+	return func(personio *Client) {
+		personio.daysCountThreshold = threshold
+		personio.daysCountCheck = check
+	}
+}
+
+// checkDaysCount calls the check configured via WithDaysCountCheck for each time-off failing it
+func (personio *Client) checkDaysCount(timeOffs []*TimeOff) {
+	if personio.daysCountCheck == nil {
+		return
+	}
+	for _, timeOff := range timeOffs {
+		if timeOff == nil || timeOff.MeasurementUnit == "hours" {
+			continue
+		}
+		workingDays := WorkingDays(*timeOff, nil, nil)
+		if math.Abs(timeOff.DaysCount-workingDays) > personio.daysCountThreshold {
+			personio.daysCountCheck(timeOff, workingDays)
+		}
+	}
+}
+
+// TimeOffWithinPeriod returns the number of the time-off's days falling inside the period from periodStart to periodEnd
+// (both inclusive), counting half days as 0.5, e.g. to pro-rate a leave over quarters
+//
+// The period's calendar days are taken in the location of periodStart and periodEnd, the time-off's days as in
+// ExpandTimeOffDays. Weekends and holidays are counted, see WorkingDays for excluding them.
+func TimeOffWithinPeriod(t *TimeOff, periodStart time.Time, periodEnd time.Time) float64 {
+
+	const day = 24 * time.Hour
+	startYear, startMonth, startDay := periodStart.Date()
+	endYear, endMonth, endDay := periodEnd.Date()
+	start := time.Date(startYear, startMonth, startDay, 0, 0, 0, 0, time.UTC)
+	end := time.Date(endYear, endMonth, endDay, 0, 0, 0, 0, time.UTC).Add(day)
+
+	days := 0.0
+	for _, timeOffDay := range ExpandTimeOffDays(t) {
+		if util.GetTimeIntersection(timeOffDay.Date, timeOffDay.Date.Add(day), start, end) > 0 {
+			days += timeOffDay.Fraction
+		}
+	}
+	return days
+}
+
+// AbsenceRateByDepartment returns the absence rate of each department from start to end (both inclusive), keyed by
+// department ID, e.g. for people analytics
+//
+// The rate is the department's absence days divided by its working days. Working days are the weekdays (Monday to
+// Friday) on which an employee is employed, employees without hire_date count as employed until terminated. Absence
+// days are the weekdays covered by the employees' time-offs, counting half days as 0.5. Employees without department
+// are reported under ID 0, time-offs of employees not passed are ignored and departments without working days are
+// omitted. Holidays are not considered.
+func AbsenceRateByDepartment(employees []*Employee, offs []*TimeOff, start time.Time, end time.Time) map[int64]float64 {
+
+	grouped, _ := groupTimeOffsByEmployee(offs)
+	startDay := util.FormatPersonioDate(start, nil)
+	endDay := util.FormatPersonioDate(end, nil)
+	isWorkingDay := func(day time.Time) bool {
+		date := util.FormatPersonioDate(day, nil)
+		return date >= startDay && date <= endDay && day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+	}
+
+	absenceDays := map[int64]float64{}
+	workingDays := map[int64]float64{}
+	for _, employee := range employees {
+		departmentId := int64(0)
+		if id := employee.nestedId("department"); id != nil {
+			departmentId = *id
+		}
+
+		year, month, day := start.Date()
+		for date := time.Date(year, month, day, 0, 0, 0, 0, start.Location()); util.FormatPersonioDate(date, nil) <= endDay; date = date.AddDate(0, 0, 1) {
+			hired, terminated := employee.employedOn(date)
+			if isWorkingDay(date) && (hired || employee.GetTimeAttribute("hire_date") == nil) && !terminated {
+				workingDays[departmentId]++
+			}
+		}
+
+		employeeId := employee.GetIntAttribute("id")
+		if employeeId == nil {
+			continue
+		}
+		for _, timeOff := range grouped[*employeeId] {
+			for _, timeOffDay := range ExpandTimeOffDays(timeOff) {
+				if isWorkingDay(timeOffDay.Date) {
+					absenceDays[departmentId] += timeOffDay.Fraction
+				}
+			}
+		}
+	}
+
+	rates := make(map[int64]float64, len(workingDays))
+	for departmentId, days := range workingDays {
+		if days > 0 {
+			rates[departmentId] = absenceDays[departmentId] / days
+		}
+	}
+	return rates
+}
+
+// FindOverlaps returns the pairs of time-offs of the same employee whose date ranges overlap, e.g. to catch duplicates
+//
+// Time-offs sharing at least one calendar day overlap, time-offs on consecutive days never do. Time-offs merely touching
+// by splitting their shared boundary day into halves (e.g. one ending with HalfDayEnd on the day the other starts with
+// HalfDayStart) are reported too unless excludeTouching is set.
+// Pairs are ordered by employee ID and start date, the earlier time-off first. Time-offs without employee ID are skipped.
+func FindOverlaps(offs []*TimeOff, excludeTouching bool) [][2]*TimeOff {
+
+	grouped, _ := groupTimeOffsByEmployee(offs)
+	employeeIds := make([]int64, 0, len(grouped))
+	for employeeId := range grouped {
+		employeeIds = append(employeeIds, employeeId)
+	}
+	sort.Slice(employeeIds, func(i, j int) bool { return employeeIds[i] < employeeIds[j] })
+
+	const day = 24 * time.Hour
+	overlaps := make([][2]*TimeOff, 0)
+	for _, employeeId := range employeeIds {
+		timeOffs := append([]*TimeOff(nil), grouped[employeeId]...)
+		sort.SliceStable(timeOffs, func(i, j int) bool {
+			if !timeOffs[i].StartDate.Equal(timeOffs[j].StartDate) {
+				return timeOffs[i].StartDate.Before(timeOffs[j].StartDate)
+			}
+			return timeOffs[i].Id < timeOffs[j].Id
+		})
+
+		for i, a := range timeOffs {
+			for _, b := range timeOffs[i+1:] {
+				if util.GetTimeIntersection(a.StartDate, a.EndDate.Add(day), b.StartDate, b.EndDate.Add(day)) <= 0 {
+					continue
+				}
+				// the halves of the shared days decide whether they overlap or merely touch
+				aStart, aEnd := a.interval(nil)
+				bStart, bEnd := b.interval(a.StartDate.Location())
+				if util.GetTimeIntersection(aStart, aEnd, bStart, bEnd) > 0 || !excludeTouching {
+					overlaps = append(overlaps, [2]*TimeOff{a, b})
+				}
+			}
+		}
+	}
+
+	return overlaps
+}
@@ -0,0 +1,828 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClient_GetTimeOffsGroupedByEmployee(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	grouped, err := personio.GetTimeOffsGroupedByEmployee(nil, nil)
+	if err != nil {
+		t.Errorf("Failed to query grouped time-offs: %s", err)
+		return
+	}
+
+	wantIds := map[int64][]int64{
+		7161253: {125814620},
+		6205887: {125682392, 125682393},
+	}
+	if len(grouped) != len(wantIds) {
+		t.Errorf("Expected %d employees, got %d", len(wantIds), len(grouped))
+	}
+	for employeeId, ids := range wantIds {
+		if len(grouped[employeeId]) != len(ids) {
+			t.Errorf("Expected %d time-offs for employee %d, got %d", len(ids), employeeId, len(grouped[employeeId]))
+			continue
+		}
+		for i, id := range ids {
+			if grouped[employeeId][i].Id != id {
+				t.Errorf("Expected time-off %d for employee %d, got %d", id, employeeId, grouped[employeeId][i].Id)
+			}
+		}
+	}
+
+	// time-offs without employee
+	orphans := []*TimeOff{{Id: 1}, {Id: 2, Employee: *makeEmployee(7161253, "", "")}}
+	grouped, skipped := groupTimeOffsByEmployee(orphans)
+	if skipped != 1 || len(grouped) != 1 || len(grouped[7161253]) != 1 {
+		t.Errorf("Expected 1 skipped and 1 grouped time-off, got %d skipped and %v", skipped, grouped)
+	}
+}
+
+func TestTimeOff_IsValidHalfDay(t *testing.T) {
+
+	day := time.Date(2022, 9, 5, 0, 0, 0, 0, time.UTC)
+	nextDay := day.AddDate(0, 0, 1)
+
+	testCases := []struct {
+		start        time.Time
+		end          time.Time
+		halfDayStart PersonioBool
+		halfDayEnd   PersonioBool
+		want         bool
+	}{
+		{day, day, false, false, true},
+		{day, day, true, false, true},
+		{day, day, false, true, true},
+		{day, day, true, true, false},
+		{day, day.Add(23 * time.Hour), true, true, false},
+		{day, nextDay, true, true, true},
+		{day, nextDay, false, true, true},
+		{nextDay, day, false, false, false},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		timeOff := TimeOff{StartDate: testCase.start, EndDate: testCase.end, HalfDayStart: testCase.halfDayStart, HalfDayEnd: testCase.halfDayEnd}
+		if got := timeOff.IsValidHalfDay(); got != testCase.want {
+			t.Errorf("[%d] Expected %t, got %t", testCaseNumber, testCase.want, got)
+		}
+	}
+}
+
+func TestClient_CreateTimeOffs(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	server.mock.uniqueTokens = true
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	// employee 7161253 already has a time-off starting 2022-09-05, which the mock rejects
+	conflicting := 7
+	reqs := make([]CreateTimeOffRequest, 20)
+	for i := range reqs {
+		start := time.Date(2023, 1, 2+i, 0, 0, 0, 0, time.UTC)
+		if i == conflicting {
+			start = time.Date(2022, 9, 6, 0, 0, 0, 0, time.UTC)
+		}
+		reqs[i] = CreateTimeOffRequest{EmployeeId: 6205000 + int64(i), TimeOffTypeId: 155627, StartDate: start, EndDate: start}
+		if i == conflicting {
+			reqs[i].EmployeeId = 7161253
+		}
+	}
+
+	concurrency := 4
+	timeOffs, errs := personio.CreateTimeOffs(reqs, concurrency)
+	if len(timeOffs) != len(reqs) || len(errs) != len(reqs) {
+		t.Errorf("Expected %d results, got %d time-offs and %d errors", len(reqs), len(timeOffs), len(errs))
+		return
+	}
+
+	for i := range reqs {
+		if i == conflicting {
+			if errs[i] == nil || timeOffs[i] != nil {
+				t.Errorf("[%d] Expected error and no time-off, got %v and %v", i, timeOffs[i], errs[i])
+			}
+			continue
+		}
+		if errs[i] != nil {
+			t.Errorf("[%d] Failed to create time-off: %s", i, errs[i])
+			continue
+		}
+		if wantId := 130000000 + reqs[i].EmployeeId%1000; timeOffs[i] == nil || timeOffs[i].Id != wantId {
+			t.Errorf("[%d] Expected time-off %d, got %v", i, wantId, timeOffs[i])
+		}
+	}
+
+	// rotated tokens are reused, so at most one authentication per worker is needed
+	if server.mock.authCount > concurrency {
+		t.Errorf("Expected at most %d authentications, got %d", concurrency, server.mock.authCount)
+	}
+}
+
+func TestOverlapFraction(t *testing.T) {
+
+	makeTimeOff := func(start string, end string) *TimeOff {
+		return &TimeOff{StartDate: makeTime(start + "T00:00:00+02:00"), EndDate: makeTime(end + "T00:00:00+02:00")}
+	}
+
+	testCases := []struct {
+		a    *TimeOff
+		b    *TimeOff
+		want float64
+	}{
+		{a: makeTimeOff("2022-09-05", "2022-09-09"), b: makeTimeOff("2022-09-07", "2022-09-14"), want: 3.0 / 5.0},
+		{a: makeTimeOff("2022-09-05", "2022-09-09"), b: makeTimeOff("2022-09-06", "2022-09-06"), want: 1},
+		{a: makeTimeOff("2022-09-05", "2022-09-09"), b: makeTimeOff("2022-09-10", "2022-09-14"), want: 0},
+		{a: makeTimeOff("2022-09-05", "2022-09-05"), b: makeTimeOff("2022-09-05", "2022-09-05"), want: 1},
+		{a: makeTimeOff("2022-09-05", "2022-09-09"), b: nil, want: 0},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		if got := OverlapFraction(testCase.a, testCase.b); got != testCase.want {
+			t.Errorf("[%d] Expected %f, got %f", testCaseNumber, testCase.want, got)
+		}
+		if got := OverlapFraction(testCase.b, testCase.a); got != testCase.want {
+			t.Errorf("[%d] Expected %f reversed, got %f", testCaseNumber, testCase.want, got)
+		}
+	}
+}
+
+func TestClient_ApproveRejectTimeOff(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	server.mock.timeOffStatuses = map[int64]string{125682392: "pending", 125682393: "pending"}
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	err = personio.ApproveTimeOff(125682392)
+	if err != nil {
+		t.Errorf("Failed to approve time-off: %s", err)
+	}
+
+	err = personio.RejectTimeOff(125682393, "team offsite")
+	if err != nil {
+		t.Errorf("Failed to reject time-off: %s", err)
+	}
+
+	wantStatuses := map[int64]string{125682392: "approved", 125682393: "rejected"}
+	for id, wantStatus := range wantStatuses {
+		timeOff, err := personio.GetTimeOff(id)
+		if err != nil {
+			t.Errorf("Failed to query time-off %d: %s", id, err)
+			continue
+		}
+		if timeOff.Status != wantStatus {
+			t.Errorf("Expected time-off %d to be %s, got %s", id, wantStatus, timeOff.Status)
+		}
+	}
+
+	// neither approved nor rejected time-offs can transition again
+	for _, id := range []int64{125814620, 125682393} {
+		err = personio.ApproveTimeOff(id)
+		if !errors.Is(err, ErrInvalidStatusTransition) {
+			t.Errorf("Expected ErrInvalidStatusTransition for time-off %d, got %v", id, err)
+		}
+	}
+
+	_, err = personio.GetTimeOff(0xdeadbeef)
+	var statusErr StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != 404 {
+		t.Errorf("Expected 404 for unknown time-off, got %v", err)
+	}
+}
+
+func TestClient_GetTimeOffsWithMode(t *testing.T) {
+
+	date := func(s string) *time.Time {
+		t := makeTime(s)
+		return &t
+	}
+
+	// time-off 125814620 spans 2022-09-05 to 2022-09-09, 125682392 2022-09-07 to 2022-09-14
+	testCases := []struct {
+		start   *time.Time
+		end     *time.Time
+		mode    TimeOffRangeMode
+		wantIds []int64
+	}{
+		{start: date("2022-09-08T00:00:00Z"), end: date("2022-09-30T00:00:00Z"), mode: TimeOffRangeOverlap, wantIds: []int64{125814620, 125682392}},
+		{start: date("2022-09-08T00:00:00Z"), end: date("2022-09-30T00:00:00Z"), mode: TimeOffRangeStart, wantIds: []int64{}},
+		{start: date("2022-09-06T00:00:00Z"), end: date("2022-09-07T00:00:00Z"), mode: TimeOffRangeStart, wantIds: []int64{125682392}},
+		{start: date("2022-09-01T00:00:00Z"), end: nil, mode: TimeOffRangeStart, wantIds: []int64{125814620, 125682392, 125682393}},
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		timeOffs, err := personio.GetTimeOffsWithMode(testCase.start, testCase.end, testCase.mode, 0, intMax)
+		if err != nil {
+			t.Errorf("[%d] Failed to query time-offs: %s", testCaseNumber, err)
+			continue
+		}
+
+		ids := make([]int64, len(timeOffs))
+		for i, timeOff := range timeOffs {
+			ids[i] = timeOff.Id
+		}
+		if !reflect.DeepEqual(ids, testCase.wantIds) {
+			t.Errorf("[%d] Expected time-offs %v, got %v", testCaseNumber, testCase.wantIds, ids)
+		}
+	}
+}
+
+func TestClient_GetCurrentTimeOffs(t *testing.T) {
+
+	testCases := []struct {
+		now     string
+		wantIds []int64
+	}{
+		{now: "2022-09-08T10:00:00+02:00", wantIds: []int64{125814620, 125682392}},
+		{now: "2022-09-04T23:30:00Z", wantIds: []int64{125814620}},
+		{now: "2022-09-15T00:30:00+02:00", wantIds: []int64{}},
+		{now: "2022-12-01T09:00:00+02:00", wantIds: []int64{}},
+		{now: "2022-12-01T13:00:00+02:00", wantIds: []int64{125682393}},
+	}
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+	// the clock jumps past the token lifetime, so fresh tokens are needed
+	server.mock.uniqueTokens = true
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithLocation(time.FixedZone("CEST", 2*60*60)))
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	for testCaseNumber, testCase := range testCases {
+
+		now := makeTime(testCase.now)
+		personio.clock = func() time.Time { return now }
+
+		timeOffs, err := personio.GetCurrentTimeOffs()
+		if err != nil {
+			t.Errorf("[%d] Failed to query current time-offs: %s", testCaseNumber, err)
+			continue
+		}
+
+		if timeOffs == nil || len(timeOffs) != len(testCase.wantIds) {
+			t.Errorf("[%d] Expected %d time-offs, got %v", testCaseNumber, len(testCase.wantIds), timeOffs)
+			continue
+		}
+
+		for i, wantId := range testCase.wantIds {
+			if timeOffs[i].Id != wantId {
+				t.Errorf("[%d] Expected time-off %d, got %d", testCaseNumber, wantId, timeOffs[i].Id)
+			}
+		}
+	}
+}
+
+func TestTimeOff_Amount(t *testing.T) {
+
+	data, err := os.ReadFile(filepath.Join("testdata", "time-off-hours.json"))
+	if err != nil {
+		t.Errorf("Failed to read hours-based time-off test data: %s", err)
+		return
+	}
+
+	var hours timeOffContainer
+	err = json.Unmarshal(data, &hours)
+	if err != nil {
+		t.Errorf("Failed to unmarshal hours-based time-off: %s", err)
+		return
+	}
+
+	testCases := []struct {
+		timeOff   *TimeOff
+		wantValue float64
+		wantUnit  string
+	}{
+		{timeOff: &hours.Attributes, wantValue: 2.5, wantUnit: "hours"},
+		{timeOff: &TimeOff{DaysCount: 5}, wantValue: 5, wantUnit: "days"},
+		{timeOff: &TimeOff{DaysCount: 3, MeasurementUnit: "days", EffectiveDuration: 2.5}, wantValue: 2.5, wantUnit: "days"},
+		{timeOff: (&Absence{MeasurementUnit: "hours", EffectiveDuration: 3}).ToTimeOff(), wantValue: 3, wantUnit: "hours"},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		value, unit := testCase.timeOff.Amount()
+		if value != testCase.wantValue || unit != testCase.wantUnit {
+			t.Errorf("[%d] Expected %f %s, got %f %s", testCaseNumber, testCase.wantValue, testCase.wantUnit, value, unit)
+		}
+	}
+}
+
+func TestClient_GetLatestTimeOffPerEmployee(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	end := makeTime("2022-10-01T00:00:00Z")
+	testCases := []struct {
+		end     *time.Time
+		wantIds map[int64]int64
+	}{
+		{end: nil, wantIds: map[int64]int64{7161253: 125814620, 6205887: 125682393}},
+		{end: &end, wantIds: map[int64]int64{7161253: 125814620, 6205887: 125682392}},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		latest, err := personio.GetLatestTimeOffPerEmployee(nil, testCase.end)
+		if err != nil {
+			t.Errorf("[%d] Failed to query latest time-offs: %s", testCaseNumber, err)
+			continue
+		}
+		if len(latest) != len(testCase.wantIds) {
+			t.Errorf("[%d] Expected %d employees, got %d", testCaseNumber, len(testCase.wantIds), len(latest))
+		}
+		for employeeId, wantId := range testCase.wantIds {
+			if latest[employeeId] == nil || latest[employeeId].Id != wantId {
+				t.Errorf("[%d] Expected time-off %d for employee %d, got %v", testCaseNumber, wantId, employeeId, latest[employeeId])
+			}
+		}
+	}
+
+	// ties are broken by UpdatedAt
+	day := makeTime("2022-09-05T00:00:00Z")
+	older := &TimeOff{Id: 1, EndDate: day, UpdatedAt: day}
+	newer := &TimeOff{Id: 2, EndDate: day, UpdatedAt: day.Add(time.Hour)}
+	latest := latestTimeOffs(map[int64][]*TimeOff{42: {newer, older}})
+	if latest[42] != newer {
+		t.Errorf("Expected the later updated time-off, got %v", latest[42])
+	}
+}
+
+func TestExpandTimeOffDays(t *testing.T) {
+
+	timeOff := func(start string, end string, halfDayStart bool, halfDayEnd bool) *TimeOff {
+		return &TimeOff{StartDate: makeTime(start), EndDate: makeTime(end), HalfDayStart: PersonioBool(halfDayStart), HalfDayEnd: PersonioBool(halfDayEnd)}
+	}
+
+	testCases := []struct {
+		timeOff       *TimeOff
+		wantDays      []string
+		wantFractions []float64
+	}{
+		{timeOff: nil, wantDays: nil},
+		{timeOff: timeOff("2022-09-07T00:00:00+02:00", "2022-09-09T00:00:00+02:00", false, false), wantDays: []string{"2022-09-07", "2022-09-08", "2022-09-09"}, wantFractions: []float64{1, 1, 1}},
+		{timeOff: timeOff("2022-02-28T00:00:00Z", "2022-03-01T00:00:00Z", false, false), wantDays: []string{"2022-02-28", "2022-03-01"}, wantFractions: []float64{1, 1}},
+		{timeOff: timeOff("2022-12-01T00:00:00+02:00", "2022-12-01T00:00:00+02:00", false, false), wantDays: []string{"2022-12-01"}, wantFractions: []float64{1}},
+		{timeOff: timeOff("2022-09-09T00:00:00Z", "2022-09-07T00:00:00Z", false, false), wantDays: nil},
+		// half_day_start affects StartDate, half_day_end affects EndDate
+		{timeOff: timeOff("2022-09-07T00:00:00+02:00", "2022-09-09T00:00:00+02:00", true, false), wantDays: []string{"2022-09-07", "2022-09-08", "2022-09-09"}, wantFractions: []float64{0.5, 1, 1}},
+		{timeOff: timeOff("2022-09-07T00:00:00+02:00", "2022-09-09T00:00:00+02:00", false, true), wantDays: []string{"2022-09-07", "2022-09-08", "2022-09-09"}, wantFractions: []float64{1, 1, 0.5}},
+		{timeOff: timeOff("2022-09-07T00:00:00+02:00", "2022-09-08T00:00:00+02:00", true, true), wantDays: []string{"2022-09-07", "2022-09-08"}, wantFractions: []float64{0.5, 0.5}},
+		{timeOff: timeOff("2022-12-01T00:00:00+02:00", "2022-12-01T00:00:00+02:00", false, true), wantDays: []string{"2022-12-01"}, wantFractions: []float64{0.5}},
+		{timeOff: timeOff("2022-12-01T00:00:00+02:00", "2022-12-01T00:00:00+02:00", true, true), wantDays: []string{"2022-12-01"}, wantFractions: []float64{0.5}},
+	}
+
+	for testNumber, testCase := range testCases {
+		days := ExpandTimeOffDays(testCase.timeOff)
+		if len(days) != len(testCase.wantDays) {
+			t.Errorf("[%d] Expected %d days, got %v", testNumber, len(testCase.wantDays), days)
+			continue
+		}
+		for i, day := range days {
+			if !day.Date.Equal(makeTime(testCase.wantDays[i] + "T00:00:00Z")) {
+				t.Errorf("[%d] Expected day %s, got %s", testNumber, testCase.wantDays[i], day.Date)
+			}
+			if day.Fraction != testCase.wantFractions[i] {
+				t.Errorf("[%d] Expected fraction %v on %s, got %v", testNumber, testCase.wantFractions[i], testCase.wantDays[i], day.Fraction)
+			}
+		}
+	}
+}
+
+func TestClient_GetMonthAbsenceCalendar(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	testCases := []struct {
+		month    time.Month
+		wantLen  int
+		wantDays map[string][]int64
+	}{
+		{month: time.September, wantLen: 30, wantDays: map[string][]int64{
+			"2022-09-01": {},
+			"2022-09-05": {7161253},
+			"2022-09-07": {6205887, 7161253},
+			"2022-09-09": {6205887, 7161253},
+			"2022-09-14": {6205887},
+			"2022-09-15": {},
+		}},
+		{month: time.December, wantLen: 31, wantDays: map[string][]int64{
+			"2022-12-01": {6205887},
+			"2022-12-02": {},
+		}},
+	}
+
+	for testNumber, testCase := range testCases {
+		calendar, err := personio.GetMonthAbsenceCalendar(2022, testCase.month)
+		if err != nil {
+			t.Errorf("[%d] Failed to query month calendar: %s", testNumber, err)
+			continue
+		}
+		if len(calendar) != testCase.wantLen {
+			t.Errorf("[%d] Expected %d days, got %d", testNumber, testCase.wantLen, len(calendar))
+		}
+		for day, wantIds := range testCase.wantDays {
+			ids, ok := calendar[makeTime(day+"T00:00:00Z")]
+			if !ok || ids == nil {
+				t.Errorf("[%d] Expected day %s in calendar", testNumber, day)
+				continue
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(wantIds) {
+				t.Errorf("[%d] Expected employees %v on %s, got %v", testNumber, wantIds, day, ids)
+			}
+		}
+	}
+}
+
+func TestTimeOff_EmployeeIDEmail(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	timeOffs, err := personio.GetTimeOffs(nil, nil, 0, intMax)
+	if err != nil {
+		t.Errorf("Failed to query time-offs: %s", err)
+		return
+	}
+
+	wantEmployees := map[int64]struct {
+		id    int64
+		email string
+	}{
+		125814620: {id: 7161253, email: "mega@giantswarm.io"},
+		125682392: {id: 6205887, email: "gonzo@giantswarm.io"},
+		125682393: {id: 6205887, email: "gonzo@giantswarm.io"},
+	}
+
+	if len(timeOffs) != len(wantEmployees) {
+		t.Errorf("Expected %d time-offs, got %d", len(wantEmployees), len(timeOffs))
+	}
+	for _, timeOff := range timeOffs {
+		want := wantEmployees[timeOff.Id]
+		if id := timeOff.EmployeeID(); id == nil || *id != want.id {
+			t.Errorf("[%d] Expected employee ID %d, got %v", timeOff.Id, want.id, id)
+		}
+		if email := timeOff.EmployeeEmail(); email == nil || *email != want.email {
+			t.Errorf("[%d] Expected employee email %s, got %v", timeOff.Id, want.email, email)
+		}
+	}
+
+	var missing *TimeOff
+	if missing.EmployeeID() != nil || missing.EmployeeEmail() != nil || (&TimeOff{}).EmployeeID() != nil {
+		t.Errorf("Expected nil for missing employee")
+	}
+}
+
+func TestWorkingDays(t *testing.T) {
+
+	// 2022-09-05 is a Monday
+	timeOff := func(start string, end string, halfDayStart bool, halfDayEnd bool) TimeOff {
+		return TimeOff{StartDate: makeTime(start + "T00:00:00+02:00"), EndDate: makeTime(end + "T00:00:00+02:00"), HalfDayStart: PersonioBool(halfDayStart), HalfDayEnd: PersonioBool(halfDayEnd)}
+	}
+	holidays := []time.Time{makeTime("2022-09-07T00:00:00Z"), makeTime("2022-09-12T00:00:00+02:00"), makeTime("2022-09-10T00:00:00Z")}
+
+	testCases := []struct {
+		timeOff  TimeOff
+		weekends []time.Weekday
+		holidays []time.Time
+		want     float64
+	}{
+		// two weeks minus the weekend
+		{timeOff: timeOff("2022-09-05", "2022-09-16", false, false), want: 10},
+		// holiday mid-range, holiday on a weekend is not subtracted twice
+		{timeOff: timeOff("2022-09-05", "2022-09-16", false, false), holidays: holidays, want: 8},
+		// holiday on the start date, its half day does not count either
+		{timeOff: timeOff("2022-09-12", "2022-09-16", true, false), holidays: holidays, want: 4},
+		{timeOff: timeOff("2022-09-13", "2022-09-16", true, true), holidays: holidays, want: 3},
+		{timeOff: timeOff("2022-09-07", "2022-09-07", false, true), want: 0.5},
+		{timeOff: timeOff("2022-09-07", "2022-09-07", false, true), holidays: holidays, want: 0},
+		// custom weekends
+		{timeOff: timeOff("2022-09-05", "2022-09-11", false, false), weekends: []time.Weekday{time.Friday, time.Saturday}, want: 5},
+		{timeOff: timeOff("2022-09-05", "2022-09-11", false, false), weekends: []time.Weekday{}, want: 7},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		got := WorkingDays(testCase.timeOff, testCase.weekends, testCase.holidays)
+		if got != testCase.want {
+			t.Errorf("[%d] Expected %.1f working days, got %.1f", testCaseNumber, testCase.want, got)
+		}
+	}
+}
+
+func TestFindOverlaps(t *testing.T) {
+
+	makeTimeOff := func(id int64, employeeId int64, start string, end string) *TimeOff {
+		return &TimeOff{
+			Id:        id,
+			StartDate: makeTime(start + "T00:00:00+02:00"),
+			EndDate:   makeTime(end + "T00:00:00+02:00"),
+			Employee:  Employee{AttributeContainer: AttributeContainer{Attributes: map[string]Attribute{"id": {Type: "integer", Value: float64(employeeId)}}}},
+		}
+	}
+	makeHalfDays := func(timeOff *TimeOff, halfDayStart bool, halfDayEnd bool) *TimeOff {
+		timeOff.HalfDayStart, timeOff.HalfDayEnd = PersonioBool(halfDayStart), PersonioBool(halfDayEnd)
+		return timeOff
+	}
+
+	offs := []*TimeOff{
+		// nested: 2 and 3 lie within 1, 3 partially overlaps 2
+		makeTimeOff(3, 1, "2022-09-07", "2022-09-12"),
+		makeTimeOff(1, 1, "2022-09-05", "2022-09-16"),
+		makeTimeOff(2, 1, "2022-09-06", "2022-09-08"),
+		// consecutive: 5 starts the day after 4 ends
+		makeTimeOff(4, 2, "2022-09-05", "2022-09-09"),
+		makeTimeOff(5, 2, "2022-09-10", "2022-09-14"),
+		// touching: 9 takes the afternoon of the day 8 ends with a morning off, 10 takes that day's afternoon too
+		makeHalfDays(makeTimeOff(8, 4, "2022-09-05", "2022-09-09"), false, true),
+		makeHalfDays(makeTimeOff(9, 4, "2022-09-09", "2022-09-14"), true, false),
+		makeHalfDays(makeTimeOff(10, 4, "2022-09-09", "2022-09-09"), false, true),
+		// partial overlap with another employee's time-off is no overlap
+		makeTimeOff(6, 3, "2022-09-01", "2022-09-06"),
+		{Id: 7, StartDate: makeTime("2022-09-01T00:00:00Z"), EndDate: makeTime("2022-09-30T00:00:00Z")},
+	}
+
+	testCases := []struct {
+		excludeTouching bool
+		want            [][2]int64
+	}{
+		{excludeTouching: false, want: [][2]int64{{1, 2}, {1, 3}, {2, 3}, {8, 9}, {8, 10}, {9, 10}}},
+		{excludeTouching: true, want: [][2]int64{{1, 2}, {1, 3}, {2, 3}, {9, 10}}},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		overlaps := FindOverlaps(offs, testCase.excludeTouching)
+		got := make([][2]int64, len(overlaps))
+		for i, pair := range overlaps {
+			got[i] = [2]int64{pair[0].Id, pair[1].Id}
+		}
+		if !reflect.DeepEqual(got, testCase.want) {
+			t.Errorf("[%d] Expected overlaps %v, got %v", testCaseNumber, testCase.want, got)
+		}
+	}
+
+	if overlaps := FindOverlaps(nil, false); overlaps == nil || len(overlaps) != 0 {
+		t.Errorf("Expected no overlaps, got %v", overlaps)
+	}
+}
+
+func TestClient_WithDaysCountCheck(t *testing.T) {
+
+	testCases := []struct {
+		threshold float64
+		wantIds   []int64
+	}{
+		// the fixtures' DaysCount agree with their working days
+		{threshold: 0, wantIds: []int64{}},
+		// a negative threshold reports every time-off
+		{threshold: -1, wantIds: []int64{125814620, 125682392, 125682393}},
+	}
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	for testCaseNumber, testCase := range testCases {
+		server, err := newTestServer()
+		if err != nil {
+			t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+			return
+		}
+
+		reported := []int64{}
+		workingDays := map[int64]float64{}
+		check := func(timeOff *TimeOff, days float64) {
+			reported = append(reported, timeOff.Id)
+			workingDays[timeOff.Id] = days
+		}
+
+		personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithDaysCountCheck(testCase.threshold, check))
+		if err != nil {
+			_ = server.Close()
+			t.Errorf("[%d] Failed to create Personio API v1 client: %s", testCaseNumber, err)
+			continue
+		}
+
+		timeOffs, err := personio.GetTimeOffs(nil, nil, 0, intMax)
+		_ = server.Close()
+		if err != nil {
+			t.Errorf("[%d] Failed to query time-offs: %s", testCaseNumber, err)
+			continue
+		}
+		if len(timeOffs) != 3 {
+			t.Errorf("[%d] Expected 3 time-offs, got %d", testCaseNumber, len(timeOffs))
+		}
+		if !reflect.DeepEqual(reported, testCase.wantIds) {
+			t.Errorf("[%d] Expected reported time-offs %v, got %v", testCaseNumber, testCase.wantIds, reported)
+		}
+		if len(reported) == 3 && (workingDays[125814620] != 5 || workingDays[125682392] != 6 || workingDays[125682393] != 0.5) {
+			t.Errorf("[%d] Unexpected working days %v", testCaseNumber, workingDays)
+		}
+	}
+
+	// Monday to Friday with a bank holiday reported as 4 days, hour-based time-offs are not checked
+	var reported []*TimeOff
+	personio, err := NewClient(context.TODO(), "http://localhost:0", personioCredentials, WithDaysCountCheck(0.5, func(timeOff *TimeOff, _ float64) {
+		reported = append(reported, timeOff)
+	}))
+	if err != nil {
+		t.Fatalf("Failed to create Personio API v1 client: %s", err)
+	}
+	week := TimeOff{Id: 1, StartDate: makeTime("2022-09-05T00:00:00Z"), EndDate: makeTime("2022-09-09T00:00:00Z")}
+	holiday, nearly, hours := week, week, week
+	holiday.DaysCount, nearly.DaysCount, hours.DaysCount = 4, 4.5, 1
+	hours.MeasurementUnit = "hours"
+	personio.checkDaysCount([]*TimeOff{&holiday, &nearly, &hours})
+	if len(reported) != 1 || reported[0] != &holiday {
+		t.Errorf("Expected only the time-off deviating by a day to be reported, got %v", reported)
+	}
+}
+
+func TestTimeOffWithinPeriod(t *testing.T) {
+
+	timeOff := func(start string, end string, halfDayStart bool, halfDayEnd bool) *TimeOff {
+		return &TimeOff{StartDate: makeTime(start + "T00:00:00+02:00"), EndDate: makeTime(end + "T00:00:00+02:00"), HalfDayStart: PersonioBool(halfDayStart), HalfDayEnd: PersonioBool(halfDayEnd)}
+	}
+	q3Start, q3End := makeTime("2022-07-01T00:00:00Z"), makeTime("2022-09-30T00:00:00Z")
+	q4Start, q4End := makeTime("2022-10-01T00:00:00Z"), makeTime("2022-12-31T00:00:00Z")
+
+	testCases := []struct {
+		timeOff *TimeOff
+		start   time.Time
+		end     time.Time
+		want    float64
+	}{
+		// spanning the end of Q3, weekends included
+		{timeOff: timeOff("2022-09-28", "2022-10-04", false, false), start: q3Start, end: q3End, want: 3},
+		{timeOff: timeOff("2022-09-28", "2022-10-04", false, false), start: q4Start, end: q4End, want: 4},
+		{timeOff: timeOff("2022-09-28", "2022-10-04", true, true), start: q3Start, end: q3End, want: 2.5},
+		{timeOff: timeOff("2022-09-28", "2022-10-04", true, true), start: q4Start, end: q4End, want: 3.5},
+		// entirely inside and outside
+		{timeOff: timeOff("2022-12-01", "2022-12-01", false, true), start: q4Start, end: q4End, want: 0.5},
+		{timeOff: timeOff("2022-12-01", "2022-12-01", false, true), start: q3Start, end: q3End, want: 0},
+		// single-day period in another location
+		{timeOff: timeOff("2022-09-28", "2022-10-04", false, false), start: makeTime("2022-09-30T23:00:00-05:00"), end: makeTime("2022-09-30T23:00:00-05:00"), want: 1},
+		{timeOff: nil, start: q3Start, end: q3End, want: 0},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		if got := TimeOffWithinPeriod(testCase.timeOff, testCase.start, testCase.end); got != testCase.want {
+			t.Errorf("[%d] Expected %v days, got %v", testCaseNumber, testCase.want, got)
+		}
+	}
+}
+
+func TestAbsenceRateByDepartment(t *testing.T) {
+
+	withDepartment := func(employee *Employee, departmentId int64) *Employee {
+		employee.Attributes["department"] = Attribute{Label: "Department", Type: "standard", UniversalId: "department", Value: map[string]interface{}{
+			"type":       "Department",
+			"attributes": map[string]interface{}{"id": float64(departmentId), "name": "Paper Cutters"},
+		}}
+		return employee
+	}
+	timeOff := func(employee *Employee, start string, end string, halfDayStart bool) *TimeOff {
+		return &TimeOff{Employee: *employee, StartDate: makeTime(start + "T00:00:00Z"), EndDate: makeTime(end + "T00:00:00Z"), HalfDayStart: PersonioBool(halfDayStart)}
+	}
+
+	employees := []*Employee{
+		withDepartment(makeEmployee(1, "2022-01-12T00:00:00Z", ""), 100),
+		// hired on Monday, 2022-09-19
+		withDepartment(makeEmployee(2, "2022-09-19T00:00:00Z", ""), 100),
+		// no department and no hire date
+		makeEmployee(3, "", ""),
+		// terminated before the period
+		withDepartment(makeEmployee(4, "2022-01-12T00:00:00Z", "2022-08-31T00:00:00Z"), 200),
+	}
+	offs := []*TimeOff{
+		timeOff(employees[0], "2022-09-05", "2022-09-09", false),
+		timeOff(employees[1], "2022-09-28", "2022-10-04", true),
+		timeOff(makeEmployee(99, "", ""), "2022-09-05", "2022-09-09", false),
+	}
+
+	// September 2022 has 22 weekdays, employee 2 works 10 of them
+	got := AbsenceRateByDepartment(employees, offs, makeTime("2022-09-01T00:00:00Z"), makeTime("2022-09-30T00:00:00Z"))
+	want := map[int64]float64{100: 7.5 / 32, 0: 0}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected rates %v, got %v", want, got)
+	}
+}
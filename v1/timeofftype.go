@@ -0,0 +1,235 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// ErrTimeOffTypeUnsupported is returned by CreateTimeOff if WithTimeOffTypeCheck is set and the time-off type does not
+// support the requested time-off
+var ErrTimeOffTypeUnsupported = errors.New("time-off type does not support the requested time-off")
+
+// DefaultTimeOffTypesTTL is the default duration time-off types are cached for
+const DefaultTimeOffTypesTTL = time.Hour
+
+// TimeOffType is a time-off type configured in Personio
+//
+// Unit is "day" or "hour". Capabilities are false if Personio does not report them.
+type TimeOffType struct {
+	Id                     int64  `json:"id"`
+	Name                   string `json:"name"`
+	Category               string `json:"category"`
+	Unit                   string `json:"unit,omitempty"`
+	HalfDayRequestsEnabled bool   `json:"half_day_requests_enabled,omitempty"`
+	CertificationRequired  bool   `json:"certification_required,omitempty"`
+}
+
+// timeOffTypeContainer is the typed object returned for time-off types by Personio
+type timeOffTypeContainer struct {
+	Type       string      `json:"type"`
+	Attributes TimeOffType `json:"attributes"`
+}
+
+// WithTimeOffTypesTTL sets the duration time-off types are cached for (0 disables caching)
+func WithTimeOffTypesTTL(ttl time.Duration) Option {
+	return func(personio *Client) {
+		personio.timeOffTypesTTL = ttl
+	}
+}
+
+// WithTimeOffTypeCheck makes CreateTimeOff check the requested time-off against the capabilities of its (cached)
+// time-off type before sending it, failing with ErrTimeOffTypeUnsupported instead of an obscure Personio error
+//
+// The check costs a request whenever the time-off types are not cached.
+func WithTimeOffTypeCheck() Option {
+	return func(personio *Client) {
+		personio.timeOffTypeCheck = true
+	}
+}
+
+// fetchTimeOffTypes fetches all time-off types from Personio with the requests bound to ctx like in getPage
+func (personio *Client) fetchTimeOffTypes(ctx context.Context) ([]TimeOffType, error) {
+
+	results, count, err := personio.getPagesUpTo(ctx, "/company/time-off-types", url.Values{}, 0, intMax, intMax)
+	if err != nil {
+		return nil, err
+	}
+
+	// unpack TimeOffType elements
+	timeOffTypes := make([]TimeOffType, count)
+	idx := 0
+	for i := range results {
+		for j := range results[i].Data {
+			var result timeOffTypeContainer
+			err = json.Unmarshal(results[i].Data[j], &result)
+			if err != nil {
+				return nil, err
+			}
+			timeOffTypes[idx] = result.Attributes
+			idx++
+		}
+	}
+
+	return timeOffTypes, nil
+}
+
+// copyTimeOffTypes returns copies of the cached time-off types so callers can't modify the cache
+func copyTimeOffTypes(timeOffTypes []TimeOffType) []*TimeOffType {
+	copied := make([]*TimeOffType, len(timeOffTypes))
+	for i := range timeOffTypes {
+		timeOffType := timeOffTypes[i]
+		copied[i] = &timeOffType
+	}
+	return copied
+}
+
+// GetTimeOffTypes returns all time-off types, served from cache until the configured TTL expires
+func (personio *Client) GetTimeOffTypes() ([]*TimeOffType, error) {
+	return personio.getTimeOffTypes(context.Background())
+}
+
+// getTimeOffTypes is GetTimeOffTypes with a fetch bound to ctx like in getPage
+func (personio *Client) getTimeOffTypes(ctx context.Context) ([]*TimeOffType, error) {
+
+	personio.timeOffTypesMutex.Lock()
+	defer personio.timeOffTypesMutex.Unlock()
+
+	if personio.timeOffTypes != nil && personio.clock().Sub(personio.timeOffTypesFetched) < personio.timeOffTypesTTL {
+		return copyTimeOffTypes(personio.timeOffTypes), nil
+	}
+
+	timeOffTypes, err := personio.fetchTimeOffTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	personio.timeOffTypes = timeOffTypes
+	personio.timeOffTypesFetched = personio.clock()
+
+	return copyTimeOffTypes(timeOffTypes), nil
+}
+
+// RefreshTimeOffTypes invalidates the cached time-off types and fetches them again
+func (personio *Client) RefreshTimeOffTypes() error {
+
+	personio.timeOffTypesMutex.Lock()
+	personio.timeOffTypes = nil
+	personio.timeOffTypesMutex.Unlock()
+
+	_, err := personio.GetTimeOffTypes()
+	return err
+}
+
+// GetTimeOffCategories returns the distinct categories of the time-off types, sorted and served from the type cache
+func (personio *Client) GetTimeOffCategories() ([]string, error) {
+
+	timeOffTypes, err := personio.GetTimeOffTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	categories := make([]string, 0)
+	for _, timeOffType := range timeOffTypes {
+		if timeOffType.Category == "" || seen[timeOffType.Category] {
+			continue
+		}
+		seen[timeOffType.Category] = true
+		categories = append(categories, timeOffType.Category)
+	}
+	sort.Strings(categories)
+
+	return categories, nil
+}
+
+// EnrichTimeOffTypeNames sets TypeName of each time-off to the name of its time-off type, using the cached time-off types
+//
+// Time-offs of types unknown to GetTimeOffTypes keep the name embedded in the time-off, nil entries are skipped.
+func (personio *Client) EnrichTimeOffTypeNames(offs []*TimeOff) error {
+
+	timeOffTypes, err := personio.GetTimeOffTypes()
+	if err != nil {
+		return err
+	}
+
+	names := make(map[int64]string, len(timeOffTypes))
+	for _, timeOffType := range timeOffTypes {
+		names[timeOffType.Id] = timeOffType.Name
+	}
+
+	for _, timeOff := range offs {
+		if timeOff == nil {
+			continue
+		}
+		if name, ok := names[timeOff.TimeOffType.Attributes.Id]; ok {
+			timeOff.TypeName = name
+		} else {
+			timeOff.TypeName = timeOff.TimeOffType.Attributes.Name
+		}
+	}
+
+	return nil
+}
+
+// checkTimeOffType checks the requested time-off against the capabilities of its time-off type
+//
+// Required certificates don't prevent creating time-offs, they are submitted separately.
+func (personio *Client) checkTimeOffType(ctx context.Context, timeOff CreateTimeOffRequest) error {
+
+	timeOffTypes, err := personio.getTimeOffTypes(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, timeOffType := range timeOffTypes {
+		if timeOffType.Id != timeOff.TimeOffTypeId {
+			continue
+		}
+		if timeOffType.Unit == "hour" {
+			return fmt.Errorf("%w: %q (%d) is measured in hours, only day-based time-offs can be created", ErrTimeOffTypeUnsupported, timeOffType.Name, timeOffType.Id)
+		}
+		if (timeOff.HalfDayStart || timeOff.HalfDayEnd) && !timeOffType.HalfDayRequestsEnabled {
+			return fmt.Errorf("%w: %q (%d) does not allow half days", ErrTimeOffTypeUnsupported, timeOffType.Name, timeOffType.Id)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: unknown time-off type %d", ErrTimeOffTypeUnsupported, timeOff.TimeOffTypeId)
+}
+
+// CanCreateTimeOff returns whether time-offs of the specified type can likely be created for the employee
+//
+// Personio offers no permission check, so this is a best-effort probe to fail early with a clear answer: the
+// time-off type must be known and day-based (see WithTimeOffTypeCheck) and the employee's absence balance must be
+// readable, which Personio denies with 403 for employees outside the credentials' access rights. Creating the
+// time-off may still fail, e.g. if the credentials may read but not write absences.
+func (personio *Client) CanCreateTimeOff(employeeId int64, typeId int64) (bool, error) {
+
+	err := personio.checkTimeOffType(context.Background(), CreateTimeOffRequest{EmployeeId: employeeId, TimeOffTypeId: typeId})
+	if errors.Is(err, ErrTimeOffTypeUnsupported) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, personio.baseUrl+fmt.Sprintf("/company/employees/%d/absences/balance", employeeId), nil)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = personio.doRequestJson(req, true)
+	var statusErr StatusError
+	if errors.As(err, &statusErr) && (statusErr.Code == http.StatusForbidden || statusErr.Code == http.StatusNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
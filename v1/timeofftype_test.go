@@ -0,0 +1,284 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_GetTimeOffTypes(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	// tokens expire along with the cache
+	server.mock.uniqueTokens = true
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+	now := makeTime("2022-09-05T12:00:00Z")
+	personio.clock = func() time.Time { return now }
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			timeOffTypes, err := personio.GetTimeOffTypes()
+			if err != nil {
+				t.Errorf("[%d] Failed to query time-off types: %s", i, err)
+				return
+			}
+			if len(timeOffTypes) != 4 || timeOffTypes[0].Id != 155627 || timeOffTypes[0].Name != "Vacation" {
+				t.Errorf("[%d] Unexpected time-off types: %v", i, timeOffTypes)
+			}
+			// must not affect the cache
+			timeOffTypes[0].Name = "modified"
+		}(i)
+	}
+	wg.Wait()
+
+	if server.mock.timeOffTypesCount != 1 {
+		t.Errorf("Expected time-off types to be fetched once, got %d", server.mock.timeOffTypesCount)
+	}
+
+	timeOffTypes, err := personio.GetTimeOffTypes()
+	if err != nil || timeOffTypes[0].Name != "Vacation" {
+		t.Errorf("Expected cached time-off types to be unmodified, got %v (%v)", timeOffTypes, err)
+	}
+
+	err = personio.RefreshTimeOffTypes()
+	if err != nil {
+		t.Errorf("Failed to refresh time-off types: %s", err)
+	}
+	if server.mock.timeOffTypesCount != 2 {
+		t.Errorf("Expected time-off types to be fetched again after refresh, got %d fetches", server.mock.timeOffTypesCount)
+	}
+
+	// cache about to expire
+	now = now.Add(DefaultTimeOffTypesTTL - time.Second)
+	_, err = personio.GetTimeOffTypes()
+	if err != nil {
+		t.Errorf("Failed to query time-off types: %s", err)
+	}
+	if server.mock.timeOffTypesCount != 2 {
+		t.Errorf("Expected time-off types to be served from cache within TTL, got %d fetches", server.mock.timeOffTypesCount)
+	}
+
+	// expired cache
+	now = now.Add(time.Second)
+	_, err = personio.GetTimeOffTypes()
+	if err != nil {
+		t.Errorf("Failed to query time-off types: %s", err)
+	}
+	if server.mock.timeOffTypesCount != 3 {
+		t.Errorf("Expected time-off types to be fetched again after TTL, got %d fetches", server.mock.timeOffTypesCount)
+	}
+}
+
+func TestClient_EnrichTimeOffTypeNames(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	sick := &TimeOff{Id: 1}
+	sick.TimeOffType.Attributes.Id = 155628
+	unknown := &TimeOff{Id: 2}
+	unknown.TimeOffType.Attributes.Id = 999
+	unknown.TimeOffType.Attributes.Name = "Sabbatical"
+
+	for i := 0; i < 2; i++ {
+		err = personio.EnrichTimeOffTypeNames([]*TimeOff{sick, nil, unknown})
+		if err != nil {
+			t.Errorf("[%d] Failed to enrich time-off type names: %s", i, err)
+			continue
+		}
+		if sick.TypeName != "Sick leave" {
+			t.Errorf("[%d] Expected type name \"Sick leave\", got \"%s\"", i, sick.TypeName)
+		}
+		if unknown.TypeName != "Sabbatical" {
+			t.Errorf("[%d] Expected embedded type name \"Sabbatical\", got \"%s\"", i, unknown.TypeName)
+		}
+	}
+
+	if server.mock.timeOffTypesCount != 1 {
+		t.Errorf("Expected time-off types to be fetched once, got %d", server.mock.timeOffTypesCount)
+	}
+}
+
+func TestClient_GetTimeOffCategories(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	categories, err := personio.GetTimeOffCategories()
+	if err != nil {
+		t.Errorf("Failed to query time-off categories: %s", err)
+		return
+	}
+	if strings.Join(categories, ",") != "other,paid_vacation,parental_leave,sick_leave" {
+		t.Errorf("Unexpected time-off categories %v", categories)
+	}
+
+	// duplicate and empty categories are skipped, cached types are used
+	personio.timeOffTypesMutex.Lock()
+	personio.timeOffTypes = append(personio.timeOffTypes, TimeOffType{Id: 1, Name: "Overtime", Category: "other"}, TimeOffType{Id: 2, Name: "Legacy"})
+	personio.timeOffTypesMutex.Unlock()
+
+	categories, err = personio.GetTimeOffCategories()
+	if err != nil || len(categories) != 4 {
+		t.Errorf("Expected 4 distinct categories, got %v (%v)", categories, err)
+	}
+	if server.mock.timeOffTypesCount != 1 {
+		t.Errorf("Expected time-off types to be fetched once, got %d", server.mock.timeOffTypesCount)
+	}
+}
+
+func TestClient_WithTimeOffTypeCheck(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials, WithTimeOffTypeCheck())
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	request := func(timeOffTypeId int64, halfDayEnd bool) CreateTimeOffRequest {
+		return CreateTimeOffRequest{EmployeeId: 6205887, TimeOffTypeId: timeOffTypeId, StartDate: makeTime("2022-10-03T00:00:00Z"), EndDate: makeTime("2022-10-03T00:00:00Z"), HalfDayEnd: halfDayEnd}
+	}
+
+	testCases := []struct {
+		request     CreateTimeOffRequest
+		wantMessage string
+	}{
+		// vacation allows half days, sick leave requires a certificate submitted later
+		{request: request(155627, true)},
+		{request: request(155628, false)},
+		{request: request(155630, true), wantMessage: "does not allow half days"},
+		{request: request(155629, false), wantMessage: "measured in hours"},
+		{request: request(4711, false), wantMessage: "unknown time-off type 4711"},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		server.mock.lastTimeOffBody = nil
+		_, _, err := personio.CreateTimeOff(testCase.request)
+
+		if testCase.wantMessage == "" {
+			if err != nil {
+				t.Errorf("[%d] Failed to create time-off: %s", testCaseNumber, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrTimeOffTypeUnsupported) || !strings.Contains(err.Error(), testCase.wantMessage) {
+			t.Errorf("[%d] Expected ErrTimeOffTypeUnsupported with %q, got %v", testCaseNumber, testCase.wantMessage, err)
+		}
+		if server.mock.lastTimeOffBody != nil {
+			t.Errorf("[%d] Expected no request to create the time-off", testCaseNumber)
+		}
+	}
+
+	// the time-off types are fetched once
+	if server.mock.timeOffTypesCount != 1 {
+		t.Errorf("Expected time-off types to be fetched once, got %d", server.mock.timeOffTypesCount)
+	}
+}
+
+func TestClient_CanCreateTimeOff(t *testing.T) {
+
+	server, err := newTestServer()
+	if err != nil {
+		t.Errorf("Failed to setup mock Personio server: failed to listen: %s", err)
+		return
+	}
+
+	defer func() {
+		_ = server.Close()
+	}()
+	server.mock.forbiddenEmployees = map[int64]bool{7161253: true}
+
+	personioCredentials := Credentials{ClientId: "abc", ClientSecret: "def"}
+	personio, err := NewClient(context.TODO(), fmt.Sprintf("http://localhost:%d", server.port), personioCredentials)
+	if err != nil {
+		t.Errorf("Failed to create Personio API v1 client: %s", err)
+		return
+	}
+
+	testCases := []struct {
+		employeeId int64
+		typeId     int64
+		want       bool
+	}{
+		{employeeId: 6205887, typeId: 155627, want: true},
+		// no access to the employee
+		{employeeId: 7161253, typeId: 155627, want: false},
+		// unknown employee
+		{employeeId: 4711, typeId: 155627, want: false},
+		// hour-based and unknown types
+		{employeeId: 6205887, typeId: 155629, want: false},
+		{employeeId: 6205887, typeId: 4711, want: false},
+	}
+
+	for testCaseNumber, testCase := range testCases {
+		got, err := personio.CanCreateTimeOff(testCase.employeeId, testCase.typeId)
+		if err != nil {
+			t.Errorf("[%d] Failed to check time-off creation: %s", testCaseNumber, err)
+			continue
+		}
+		if got != testCase.want {
+			t.Errorf("[%d] Expected %v, got %v", testCaseNumber, testCase.want, got)
+		}
+	}
+}